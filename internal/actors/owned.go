@@ -0,0 +1,175 @@
+package actors
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	MaxLevel  = 100
+	MaxIV     = 31
+	ShinyOdds = 4096 //one in this many wild pokemon is shiny, the Gen VI+ odds
+)
+
+// Natures are in PokeAPI's id order, five of them (hardy, docile, serious, bashful, quirky) change no stats
+var Natures = []string{
+	"hardy", "bold", "modest", "calm", "timid",
+	"lonely", "docile", "mild", "gentle", "hasty",
+	"adamant", "impish", "bashful", "careful", "rash",
+	"jolly", "naughty", "lax", "quirky", "naive",
+	"brave", "relaxed", "quiet", "sassy", "serious",
+}
+
+const (
+	Male       = "male"
+	Female     = "female"
+	Genderless = "genderless"
+	Unknown    = "unknown" //pokemon caught before genders were recorded
+)
+
+type Stats struct {
+	HP             int `json:"hp"`
+	Attack         int `json:"attack"`
+	Defense        int `json:"defense"`
+	SpecialAttack  int `json:"special-attack"`
+	SpecialDefense int `json:"special-defense"`
+	Speed          int `json:"speed"`
+}
+
+// Get is the value for a PokeAPI stat name, eg special-attack, 0 for anything else
+func (s Stats) Get(stat string) int {
+	switch stat {
+	case "hp":
+		return s.HP
+	case "attack":
+		return s.Attack
+	case "defense":
+		return s.Defense
+	case "special-attack":
+		return s.SpecialAttack
+	case "special-defense":
+		return s.SpecialDefense
+	case "speed":
+		return s.Speed
+	default:
+		return 0
+	}
+}
+
+// OwnedPokemon is one pokemon a trainer caught. Two of the same species are two OwnedPokemon with their own ID and rolls.
+type OwnedPokemon struct {
+	ID       int       `json:"id"`      //unique per trainer, given out in catch order
+	Species  string    `json:"species"` //PokeAPI pokemon name, eg chansey or deoxys-attack
+	Level    int       `json:"level"`
+	XP       int       `json:"xp"` //total experience, what Level needs for the species' growth rate
+	IVs      Stats     `json:"ivs"`
+	EVs      Stats     `json:"evs"` //nothing trains pokemon yet, so these are all 0
+	Nature   string    `json:"nature"`
+	Gender   string    `json:"gender"`
+	Shiny    bool      `json:"shiny"`
+	CaughtAt string    `json:"caught_at"` //location area, empty for pokemon from before this was recorded
+	CaughtOn time.Time `json:"caught_on"`
+	HP       int       `json:"hp"`   //current HP, out of MaxHP
+	Data     Pokemon   `json:"data"` //the species' PokeAPI payload, kept so inspect works without a request
+}
+
+// CatchDetails is what the wild pokemon was like when it was caught, and what its species decides about it
+type CatchDetails struct {
+	Level      int
+	HPPercent  int    //HP it had left, 1 to 100
+	GenderRate int    //from the species, chance of being female in eighths, -1 for genderless
+	GrowthRate string //from the species, eg medium-slow
+	Location   string
+	CaughtOn   time.Time
+}
+
+// NewOwnedPokemon rolls IVs, nature, gender and shininess for a freshly caught pokemon. The ID is given out by User.AddPokemon.
+func NewOwnedPokemon(rng *rand.Rand, pokemon Pokemon, details CatchDetails) OwnedPokemon {
+	level := min(max(details.Level, 1), MaxLevel)
+	owned := OwnedPokemon{
+		Species: pokemon.Name,
+		Level:   level,
+		XP:      ExperienceForLevel(details.GrowthRate, level),
+		IVs: Stats{
+			HP:             rng.IntN(MaxIV + 1),
+			Attack:         rng.IntN(MaxIV + 1),
+			Defense:        rng.IntN(MaxIV + 1),
+			SpecialAttack:  rng.IntN(MaxIV + 1),
+			SpecialDefense: rng.IntN(MaxIV + 1),
+			Speed:          rng.IntN(MaxIV + 1),
+		},
+		Nature:   Natures[rng.IntN(len(Natures))],
+		Gender:   rollGender(rng, details.GenderRate),
+		Shiny:    rng.IntN(ShinyOdds) == 0,
+		CaughtAt: details.Location,
+		CaughtOn: details.CaughtOn,
+		Data:     pokemon,
+	}
+	owned.HP = max(owned.MaxHP()*min(max(details.HPPercent, 1), 100)/100, 1)
+	return owned
+}
+
+func rollGender(rng *rand.Rand, genderRate int) string {
+	switch {
+	case genderRate < 0:
+		return Genderless
+	case rng.IntN(8) < genderRate:
+		return Female
+	default:
+		return Male
+	}
+}
+
+// MaxHP is the main series HP formula, (2*base + IV + EV/4) * level / 100 + level + 10
+func (p OwnedPokemon) MaxHP() int {
+	return (2*p.Data.BaseStat("hp")+p.IVs.HP+p.EVs.HP/4)*p.Level/100 + p.Level + 10
+}
+
+// BaseStat is the species' base value for stat, eg hp or special-attack, 0 if the payload doesn't list it
+func (p Pokemon) BaseStat(stat string) int {
+	for _, entry := range p.Stats {
+		if entry.Stat.Name == stat {
+			return entry.BaseStat
+		}
+	}
+	return 0
+}
+
+// ExperienceForLevel is the total XP a pokemon needs to reach level, by PokeAPI growth rate name. Unknown rates use medium.
+func ExperienceForLevel(growthRate string, level int) int {
+	n := min(max(level, 1), MaxLevel)
+	if n == 1 {
+		return 0 //every growth rate starts at 0, medium-slow's formula would go negative
+	}
+	cube := n * n * n
+	switch growthRate {
+	case "fast":
+		return 4 * cube / 5
+	case "slow":
+		return 5 * cube / 4
+	case "medium-slow":
+		return 6*cube/5 - 15*n*n + 100*n - 140
+	case "slow-then-very-fast": //erratic
+		switch {
+		case n < 50:
+			return cube * (100 - n) / 50
+		case n < 68:
+			return cube * (150 - n) / 100
+		case n < 98:
+			return cube * ((1911 - 10*n) / 3) / 500
+		default:
+			return cube * (160 - n) / 100
+		}
+	case "fast-then-very-slow": //fluctuating
+		switch {
+		case n < 15:
+			return cube * ((n+1)/3 + 24) / 50
+		case n < 36:
+			return cube * (n + 14) / 50
+		default:
+			return cube * (n/2 + 32) / 50
+		}
+	default:
+		return cube
+	}
+}
@@ -0,0 +1,94 @@
+package actors
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func chanseyData(t *testing.T) Pokemon {
+	t.Helper()
+	var pokemon Pokemon
+	if err := json.Unmarshal([]byte(`{"name": "chansey", "stats": [{"base_stat": 250, "stat": {"name": "hp"}}, {"base_stat": 5, "stat": {"name": "attack"}}]}`), &pokemon); err != nil {
+		t.Fatalf("unexpected error decoding chansey: %v", err)
+	}
+	return pokemon
+}
+
+func TestNewOwnedPokemon(t *testing.T) {
+	details := CatchDetails{Level: 20, HPPercent: 50, GenderRate: 8, GrowthRate: "fast", Location: "route-209-area", CaughtOn: time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)}
+	first := NewOwnedPokemon(rand.New(rand.NewPCG(5, 5)), chanseyData(t), details)
+	second := NewOwnedPokemon(rand.New(rand.NewPCG(5, 5)), chanseyData(t), details)
+	if first.IVs != second.IVs || first.Nature != second.Nature || first.Shiny != second.Shiny {
+		t.Errorf("rolls differ with the same seed: %+v and %+v", first, second)
+	}
+	if first.Species != "chansey" || first.Level != 20 || first.XP != 6400 || first.Gender != Female || first.CaughtAt != "route-209-area" {
+		t.Errorf("unexpected chansey %+v", first)
+	}
+	for _, iv := range []int{first.IVs.HP, first.IVs.Attack, first.IVs.Defense, first.IVs.SpecialAttack, first.IVs.SpecialDefense, first.IVs.Speed} {
+		if iv < 0 || iv > MaxIV {
+			t.Errorf("IV %d is out of range", iv)
+		}
+	}
+	if want := first.MaxHP() / 2; first.HP != want {
+		t.Errorf("expected half of %d HP left, received %d", first.MaxHP(), first.HP)
+	}
+
+	genderless := NewOwnedPokemon(rand.New(rand.NewPCG(5, 5)), chanseyData(t), CatchDetails{Level: 5, GenderRate: -1})
+	if genderless.Gender != Genderless || genderless.HP != 1 {
+		t.Errorf("expected a genderless pokemon on 1 HP, received %+v", genderless)
+	}
+}
+
+func TestMaxHP(t *testing.T) {
+	chansey := OwnedPokemon{Level: 50, IVs: Stats{HP: 31}, EVs: Stats{HP: 252}, Data: chanseyData(t)}
+	if got := chansey.MaxHP(); got != 357 {
+		t.Errorf("expected 357 max HP, received %d", got)
+	}
+}
+
+func TestExperienceForLevel(t *testing.T) {
+	cases := []struct {
+		rate  string
+		level int
+		want  int
+	}{
+		{rate: "medium", level: 100, want: 1000000},
+		{rate: "fast", level: 100, want: 800000},
+		{rate: "slow", level: 100, want: 1250000},
+		{rate: "medium-slow", level: 100, want: 1059860},
+		{rate: "medium-slow", level: 2, want: 9},
+		{rate: "slow-then-very-fast", level: 100, want: 600000},
+		{rate: "fast-then-very-slow", level: 100, want: 1640000},
+		{rate: "fast", level: 1, want: 0},
+		{rate: "mystery", level: 10, want: 1000},
+	}
+	for _, c := range cases {
+		if got := ExperienceForLevel(c.rate, c.level); got != c.want {
+			t.Errorf("%s at level %d: expected %d XP, received %d", c.rate, c.level, c.want, got)
+		}
+	}
+}
+
+func TestAddAndFindPokemon(t *testing.T) {
+	user := &User{CaughtPokemon: []OwnedPokemon{}}
+	for _, species := range []string{"chansey", "tentacool", "chansey"} {
+		user.AddPokemon(OwnedPokemon{Species: species})
+	}
+	if got := user.AddPokemon(OwnedPokemon{Species: "pichu"}); got.ID != 4 {
+		t.Errorf("expected the fourth pokemon to be #4, received #%d", got.ID)
+	}
+
+	if found := user.FindPokemon("chansey"); len(found) != 2 || found[0].ID != 1 || found[1].ID != 3 {
+		t.Errorf("expected chansey #1 and #3, received %+v", found)
+	}
+	for _, query := range []string{"2", "#2"} {
+		if found := user.FindPokemon(query); len(found) != 1 || found[0].Species != "tentacool" {
+			t.Errorf("%s: expected tentacool, received %+v", query, found)
+		}
+	}
+	if found := user.FindPokemon("roselia"); len(found) != 0 {
+		t.Errorf("expected no roselia, received %+v", found)
+	}
+}
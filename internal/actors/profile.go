@@ -0,0 +1,105 @@
+package actors
+
+import (
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/atomicfile"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const DefaultProfile = "default"
+
+var (
+	ErrProfileExists   = errors.New("profile already exists")
+	ErrProfileNotFound = errors.New("profile does not exist")
+	ErrInvalidProfile  = errors.New("profile names may only use lowercase letters, numbers, dashes and underscores, up to 32 characters")
+)
+
+var profileName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ProfileStore keeps one save file per trainer in a directory, plus a small file remembering which one was used last
+type ProfileStore struct {
+	dir string
+}
+
+func NewProfileStore(dir string) *ProfileStore {
+	return &ProfileStore{dir: dir}
+}
+
+// Path is the save file for a profile
+func (p *ProfileStore) Path(name string) string {
+	return filepath.Join(p.dir, name+".json")
+}
+
+func (p *ProfileStore) Exists(name string) bool {
+	_, err := os.Stat(p.Path(name))
+	return err == nil
+}
+
+// List returns every profile name, sorted
+func (p *ProfileStore) List() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(p.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".json")
+		if profileName.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Create starts a profile with an empty save so it shows up in List straight away
+func (p *ProfileStore) Create(name string) error {
+	if !profileName.MatchString(name) {
+		return ErrInvalidProfile
+	}
+	if p.Exists(name) {
+		return fmt.Errorf("%w: %s", ErrProfileExists, name)
+	}
+	return SaveGame(p.Path(name), SaveFile{
+		CaughtPokemon: []OwnedPokemon{},
+		Location:      LocationState{PreviousPage: -1},
+	})
+}
+
+func (p *ProfileStore) Delete(name string) error {
+	if !profileName.MatchString(name) {
+		return ErrInvalidProfile
+	}
+	err := os.Remove(p.Path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	return err
+}
+
+// Active returns the profile used last, or DefaultProfile if none was recorded
+func (p *ProfileStore) Active() string {
+	data, err := os.ReadFile(filepath.Join(p.dir, "active"))
+	name := strings.TrimSpace(string(data))
+	if err != nil || !profileName.MatchString(name) {
+		return DefaultProfile
+	}
+	return name
+}
+
+func (p *ProfileStore) SetActive(name string) error {
+	if !profileName.MatchString(name) {
+		return ErrInvalidProfile
+	}
+	return atomicfile.Write(filepath.Join(p.dir, "active"), []byte(name+"\n"))
+}
+
+// ValidProfileName reports whether name can be used for a profile
+func ValidProfileName(name string) bool {
+	return profileName.MatchString(name)
+}
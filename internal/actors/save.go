@@ -0,0 +1,168 @@
+package actors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/atomicfile"
+	"os"
+	"sort"
+	"time"
+)
+
+const SaveVersion = 2 //bump this and add a migration whenever the save layout changes
+
+var (
+	ErrCorruptSave = errors.New("save file is corrupt")
+	ErrFutureSave  = errors.New("save file was written by a newer version of the pokedex")
+)
+
+// SaveFile is everything needed to pick a session back up where it was left
+type SaveFile struct {
+	Version       int            `json:"version"`
+	SavedAt       time.Time      `json:"saved_at"`
+	CaughtPokemon []OwnedPokemon `json:"caught_pokemon"`
+	Location      LocationState  `json:"location"`
+	GameVersion   *GameVersion   `json:"game_version,omitempty"` //nil shows every game's data
+}
+
+// GameVersion is the game a trainer is playing, map, explore, encounter and inspect only show its data
+type GameVersion struct {
+	Name         string   `json:"name"`          //eg diamond
+	VersionGroup string   `json:"version_group"` //eg diamond-pearl, what moves are listed by
+	Regions      []string `json:"regions"`       //where map looks for locations
+}
+
+type LocationState struct {
+	Current      string       `json:"current"`        //last explored location area, empty in the starting area
+	NextPage     int          `json:"next_page"`      //page of location areas map shows next
+	PreviousPage int          `json:"previous_page"`  //page mapb shows, -1 when there is none
+	Wild         *WildPokemon `json:"wild,omitempty"` //pokemon turned up by encounter and not caught yet, so one shot commands can encounter then catch
+}
+
+type WildPokemon struct {
+	Pokemon string `json:"pokemon"`
+	Level   int    `json:"level"`
+	Method  string `json:"method"`
+	Version string `json:"version"`
+}
+
+// migrations upgrade a raw save one version at a time, migrations[n] turns version n into n+1.
+// They work on the raw json so old layouts never need a Go type of their own.
+var migrations = map[int]func(save map[string]any) error{
+	1: migrateOwnedPokemon,
+}
+
+// migrateOwnedPokemon turns version 1's caught_pokemon, one raw PokeAPI payload per species name, into a list of owned pokemon.
+// Version 1 never recorded how a pokemon was caught, so they come back at level 1 with no IVs, a neutral nature and an unknown gender.
+func migrateOwnedPokemon(save map[string]any) error {
+	caught, _ := save["caught_pokemon"].(map[string]any)
+	names := make([]string, 0, len(caught))
+	for name := range caught {
+		names = append(names, name)
+	}
+	sort.Strings(names) //map order is random, this keeps IDs the same however often a copy of the save is migrated
+	savedAt, _ := save["saved_at"].(string)
+	caughtOn, _ := time.Parse(time.RFC3339Nano, savedAt)
+
+	owned := []any{}
+	for i, name := range names {
+		data, err := json.Marshal(caught[name])
+		if err != nil {
+			return err
+		}
+		var pokemon Pokemon
+		if err := json.Unmarshal(data, &pokemon); err != nil {
+			return fmt.Errorf("caught pokemon %s: %w", name, err)
+		}
+		migrated := OwnedPokemon{ID: i + 1, Species: name, Level: 1, Nature: "hardy", Gender: Unknown, CaughtOn: caughtOn, Data: pokemon}
+		migrated.HP = migrated.MaxHP()
+
+		data, err = json.Marshal(migrated)
+		if err != nil {
+			return err
+		}
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		owned = append(owned, value)
+	}
+	save["caught_pokemon"] = owned
+	return nil
+}
+
+// SaveGame writes the save atomically, a crash mid save keeps the previous one
+func SaveGame(path string, save SaveFile) error {
+	save.Version = SaveVersion
+	save.SavedAt = time.Now()
+	data, err := json.MarshalIndent(save, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data)
+}
+
+// LoadGame reads a save, upgrading it from older versions as needed. A missing file returns os.ErrNotExist.
+// The file is never touched, whatever is wrong with it.
+func LoadGame(path string) (SaveFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SaveFile{}, err
+	}
+	return decodeSave(data, SaveVersion)
+}
+
+// BackupSave copies a save that would not load to a timestamped file next to it and returns the copy's path,
+// so the next autosave can replace the original without losing what was in it
+func BackupSave(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	backup := fmt.Sprintf("%s.bak-%s", path, time.Now().Format("20060102-150405"))
+	if err := atomicfile.Write(backup, data); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+func decodeSave(data []byte, targetVersion int) (SaveFile, error) { //targetVersion is only ever SaveVersion outside of tests
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return SaveFile{}, fmt.Errorf("%w: %v", ErrCorruptSave, err)
+	}
+
+	versionNumber, ok := raw["version"].(float64)
+	if !ok || versionNumber < 1 {
+		return SaveFile{}, fmt.Errorf("%w: missing or invalid version", ErrCorruptSave)
+	}
+	version := int(versionNumber)
+	if version > targetVersion {
+		return SaveFile{}, fmt.Errorf("%w: got version %d, this pokedex reads up to %d", ErrFutureSave, version, targetVersion)
+	}
+
+	for ; version < targetVersion; version++ {
+		migrate, exists := migrations[version]
+		if !exists {
+			return SaveFile{}, fmt.Errorf("%w: no migration from version %d", ErrCorruptSave, version)
+		}
+		if err := migrate(raw); err != nil {
+			return SaveFile{}, fmt.Errorf("%w: migrating from version %d: %v", ErrCorruptSave, version, err)
+		}
+		raw["version"] = version + 1
+	}
+
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return SaveFile{}, err
+	}
+	var save SaveFile
+	if err := json.Unmarshal(upgraded, &save); err != nil {
+		return SaveFile{}, fmt.Errorf("%w: %v", ErrCorruptSave, err)
+	}
+	if save.CaughtPokemon == nil {
+		save.CaughtPokemon = []OwnedPokemon{}
+	}
+	return save, nil
+}
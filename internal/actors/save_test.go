@@ -0,0 +1,174 @@
+package actors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveLoadGame(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.json")
+	save := SaveFile{
+		CaughtPokemon: []OwnedPokemon{{ID: 1, Species: "pikachu", Level: 12, Nature: "timid", Data: Pokemon{Name: "pikachu", BaseExperience: 112}}},
+		Location: LocationState{
+			Current:      "canalave-city-area",
+			NextPage:     2,
+			PreviousPage: 0,
+			Wild:         &WildPokemon{Pokemon: "tentacool", Level: 24, Method: "surf", Version: "diamond"},
+		},
+		GameVersion: &GameVersion{Name: "diamond", VersionGroup: "diamond-pearl", Regions: []string{"sinnoh"}},
+	}
+	if err := SaveGame(path, save); err != nil {
+		t.Errorf("unexpected error saving: %v", err)
+		return
+	}
+
+	loaded, err := LoadGame(path)
+	if err != nil {
+		t.Errorf("unexpected error loading: %v", err)
+		return
+	}
+	if loaded.Version != SaveVersion || !reflect.DeepEqual(loaded.Location, save.Location) || !reflect.DeepEqual(loaded.GameVersion, save.GameVersion) || len(loaded.CaughtPokemon) != 1 || loaded.CaughtPokemon[0].Data.BaseExperience != 112 {
+		t.Errorf("loaded save does not match, got %+v", loaded)
+	}
+
+	if _, err := LoadGame(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for a missing save, got %v", err)
+	}
+}
+
+func TestLoadGameBadFiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		wantErr  error
+	}{
+		{name: "truncated", contents: `{"version": 1, "caught_pokemon": {`, wantErr: ErrCorruptSave},
+		{name: "no version", contents: `{"caught_pokemon": {}}`, wantErr: ErrCorruptSave},
+		{name: "future version", contents: `{"version": 99, "caught_pokemon": {}}`, wantErr: ErrFutureSave},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "save.json")
+			if err := os.WriteFile(path, []byte(c.contents), 0o644); err != nil {
+				t.Errorf("unexpected error writing save: %v", err)
+				return
+			}
+
+			_, err := LoadGame(path)
+			if !errors.Is(err, c.wantErr) {
+				t.Errorf("expected %v, got %v", c.wantErr, err)
+			}
+			if kept, _ := os.ReadFile(path); string(kept) != c.contents {
+				t.Errorf("expected loading to leave the bad save alone")
+			}
+			if backups, _ := filepath.Glob(path + ".bak-*"); len(backups) != 0 {
+				t.Errorf("expected loading not to make a backup, got %v", backups)
+			}
+
+			backup, err := BackupSave(path)
+			if err != nil {
+				t.Errorf("unexpected error backing up: %v", err)
+				return
+			}
+			if kept, _ := os.ReadFile(backup); string(kept) != c.contents {
+				t.Errorf("expected backup to keep the original contents")
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("expected the bad save to stay where it was, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveMigrations(t *testing.T) {
+	migrations[SaveVersion] = func(save map[string]any) error { //pretend the next version renamed location.current to location.area
+		location := save["location"].(map[string]any)
+		location["current"] = location["area"]
+		delete(location, "area")
+		return nil
+	}
+	defer delete(migrations, SaveVersion)
+
+	raw := fmt.Sprintf(`{"version": %d, "location": {"area": "canalave-city-area"}}`, SaveVersion)
+	save, err := decodeSave([]byte(raw), SaveVersion+1)
+	if err != nil {
+		t.Errorf("unexpected error migrating: %v", err)
+		return
+	}
+	if save.Version != SaveVersion+1 || save.Location.Current != "canalave-city-area" || save.CaughtPokemon == nil {
+		t.Errorf("migration was not applied, got %+v", save)
+	}
+
+	if _, err := decodeSave([]byte(raw), SaveVersion+2); !errors.Is(err, ErrCorruptSave) {
+		t.Errorf("expected a missing migration step to fail, got %v", err)
+	}
+}
+
+func TestMigrateOwnedPokemon(t *testing.T) {
+	raw := `{"version": 1, "saved_at": "2026-03-01T09:30:00Z", "caught_pokemon": {
+		"tentacool": {"name": "tentacool", "stats": [{"base_stat": 40, "stat": {"name": "hp"}}]},
+		"chansey": {"name": "chansey", "stats": [{"base_stat": 250, "stat": {"name": "hp"}}]}
+	}}`
+	save, err := decodeSave([]byte(raw), 2)
+	if err != nil {
+		t.Errorf("unexpected error migrating: %v", err)
+		return
+	}
+	if len(save.CaughtPokemon) != 2 {
+		t.Errorf("expected 2 pokemon, got %+v", save.CaughtPokemon)
+		return
+	}
+	chansey, tentacool := save.CaughtPokemon[0], save.CaughtPokemon[1]
+	if chansey.ID != 1 || chansey.Species != "chansey" || tentacool.ID != 2 || tentacool.Species != "tentacool" {
+		t.Errorf("expected chansey #1 and tentacool #2, got %+v", save.CaughtPokemon)
+	}
+	if chansey.Level != 1 || chansey.Gender != Unknown || chansey.HP != 16 || chansey.Data.Name != "chansey" || !chansey.CaughtOn.Equal(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected migrated chansey %+v", chansey)
+	}
+}
+
+func TestProfileStore(t *testing.T) {
+	store := NewProfileStore(t.TempDir())
+	if store.Active() != DefaultProfile {
+		t.Errorf("expected %s to be active before anything was recorded", DefaultProfile)
+	}
+
+	for _, name := range []string{"misty", "brock"} {
+		if err := store.Create(name); err != nil {
+			t.Errorf("unexpected error creating %s: %v", name, err)
+			return
+		}
+	}
+	if err := store.Create("misty"); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("expected ErrProfileExists, got %v", err)
+	}
+	if err := store.Create("Team Rocket"); !errors.Is(err, ErrInvalidProfile) {
+		t.Errorf("expected ErrInvalidProfile, got %v", err)
+	}
+
+	names, err := store.List()
+	if err != nil || len(names) != 2 || names[0] != "brock" || names[1] != "misty" {
+		t.Errorf("expected [brock misty], got %v (err %v)", names, err)
+	}
+
+	if err := store.SetActive("misty"); err != nil || store.Active() != "misty" {
+		t.Errorf("expected misty to be active, got %s (err %v)", store.Active(), err)
+	}
+
+	if err := store.Delete("brock"); err != nil {
+		t.Errorf("unexpected error deleting: %v", err)
+	}
+	if err := store.Delete("brock"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected ErrProfileNotFound, got %v", err)
+	}
+	if store.Exists("brock") {
+		t.Errorf("expected brock to be gone")
+	}
+}
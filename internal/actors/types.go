@@ -1,11 +1,12 @@
 package actors
 
 type User struct { //might flesh out to more of a game alter, with inventory and what not
-	CaughtPokemon map[string]Pokemon
+	Name          string         //profile the trainer belongs to
+	CaughtPokemon []OwnedPokemon //in catch order, so IDs go up
 }
 
 type Pokemon struct {
-	Name   string `json:"name"`
+	Name      string `json:"name"`
 	Abilities []struct {
 		Ability struct {
 			Name string `json:"name"`
@@ -27,12 +28,12 @@ type Pokemon struct {
 			URL  string `json:"url"`
 		} `json:"move"`
 		VersionGroupDetails []struct {
-			LevelLearnedAt int `json:"level_learned_at"`
+			LevelLearnedAt  int `json:"level_learned_at"`
 			MoveLearnMethod struct {
 				Name string `json:"name"`
 				URL  string `json:"url"`
 			} `json:"move_learn_method"`
-			Order any `json:"order"`
+			Order        any `json:"order"`
 			VersionGroup struct {
 				Name string `json:"name"`
 				URL  string `json:"url"`
@@ -42,10 +43,14 @@ type Pokemon struct {
 	Stats []struct {
 		BaseStat int `json:"base_stat"`
 		Effort   int `json:"effort"`
-		Stat struct {
+		Stat     struct {
 			Name string `json:"name"`
 			URL  string `json:"url"`
 		} `json:"stat"`
 	} `json:"stats"`
 	BaseExperience int `json:"base_experience"`
+	Species        struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"species"` //forms like deoxys-attack share their species, and its capture rate, with deoxys
 }
@@ -1,8 +1,38 @@
 package actors
 
+import (
+	"strconv"
+	"strings"
+)
 
-func NewUser() (*User, error) {
+func NewUser(name string) (*User, error) {
+	if !ValidProfileName(name) {
+		return nil, ErrInvalidProfile
+	}
 	return &User{
-		CaughtPokemon: make(map[string]Pokemon),
+		Name:          name,
+		CaughtPokemon: []OwnedPokemon{},
 	}, nil
 }
+
+// AddPokemon gives pokemon the next free ID and keeps it, returning it with the ID set
+func (u *User) AddPokemon(pokemon OwnedPokemon) OwnedPokemon {
+	pokemon.ID = 1
+	for _, owned := range u.CaughtPokemon {
+		pokemon.ID = max(pokemon.ID, owned.ID+1)
+	}
+	u.CaughtPokemon = append(u.CaughtPokemon, pokemon)
+	return pokemon
+}
+
+// FindPokemon returns every owned pokemon matching query, either an ID like 3 or #3, or a species name which can match several
+func (u *User) FindPokemon(query string) []OwnedPokemon {
+	found := []OwnedPokemon{}
+	id, err := strconv.Atoi(strings.TrimPrefix(query, "#"))
+	for _, owned := range u.CaughtPokemon {
+		if (err == nil && owned.ID == id) || owned.Species == query {
+			found = append(found, owned)
+		}
+	}
+	return found
+}
@@ -0,0 +1,135 @@
+package capture
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// Ball is the pokeball thrown, its modifier scales the species' capture rate
+type Ball string
+
+const (
+	PokeBall   Ball = "poke"
+	GreatBall  Ball = "great"
+	UltraBall  Ball = "ultra"
+	MasterBall Ball = "master" //never fails
+)
+
+var Balls = []Ball{PokeBall, GreatBall, UltraBall, MasterBall}
+
+func ParseBall(raw string) (Ball, error) {
+	ball := Ball(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(raw)), "-ball"))
+	for _, known := range Balls {
+		if ball == known {
+			return ball, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown ball %q, expected poke, great, ultra or master", raw)
+}
+
+// String is the in game name, eg Great Ball
+func (b Ball) String() string {
+	if b == PokeBall || b == "" {
+		return "Pokeball"
+	}
+	return strings.ToUpper(string(b[:1])) + string(b[1:]) + " Ball"
+}
+
+func (b Ball) modifier() float64 {
+	switch b {
+	case GreatBall:
+		return 1.5
+	case UltraBall:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Status is the target's status condition, sleeping or frozen pokemon are the easiest to catch
+type Status string
+
+const (
+	Healthy   Status = "none"
+	Asleep    Status = "sleep"
+	Frozen    Status = "freeze"
+	Paralyzed Status = "paralysis"
+	Poisoned  Status = "poison"
+	Burned    Status = "burn"
+)
+
+var Statuses = []Status{Healthy, Asleep, Frozen, Paralyzed, Poisoned, Burned}
+
+func ParseStatus(raw string) (Status, error) {
+	status := Status(strings.ToLower(strings.TrimSpace(raw)))
+	for _, known := range Statuses {
+		if status == known {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown status %q, expected none, sleep, freeze, paralysis, poison or burn", raw)
+}
+
+func (s Status) modifier() float64 {
+	switch s {
+	case Asleep, Frozen:
+		return 2
+	case Paralyzed, Poisoned, Burned:
+		return 1.5
+	default:
+		return 1
+	}
+}
+
+// Target is the wild pokemon a ball is thrown at
+type Target struct {
+	CaptureRate int //from the species, 3 for legendaries up to 255 for the likes of caterpie
+	MaxHP       int
+	HP          int //remaining, a pokemon at 1 HP is three times as easy to catch as one at full health
+	Status      Status
+}
+
+// Result is how a throw went. Shakes is how many times the ball wobbled, 0 to 3, a caught pokemon always shows 3.
+type Result struct {
+	Shakes int
+	Caught bool
+}
+
+// CatchRate is the modified catch rate from the Gen III/IV formula,
+// ((3*MaxHP - 2*HP) * CaptureRate * ball / (3*MaxHP)) * status, between 1 and 255 where 255 is a guaranteed catch.
+func CatchRate(target Target, ball Ball) int {
+	if ball == MasterBall {
+		return 255
+	}
+	maxHP := max(target.MaxHP, 1)
+	hp := min(max(target.HP, 1), maxHP) //a fainted pokemon can't be caught, so treat anything lower as 1 HP
+	rate := math.Floor(float64((3*maxHP-2*hp)*target.CaptureRate) * ball.modifier() / float64(3*maxHP))
+	rate = math.Floor(rate * target.Status.modifier())
+	return int(min(max(rate, 1), 255))
+}
+
+// ShakeChance is the chance out of 65536 that the ball shakes once more, 1048560 / sqrt(sqrt(16711680 / rate))
+func ShakeChance(rate int) int {
+	if rate >= 255 {
+		return 65536
+	}
+	return int(1048560 / math.Floor(math.Sqrt(math.Floor(math.Sqrt(math.Floor(16711680/float64(rate)))))))
+}
+
+// Throw rolls the Gen III+ shake checks: four rolls against ShakeChance, the pokemon breaks free on the first that fails.
+// Each passed roll before the fourth is a shake, passing all four is a catch.
+func Throw(rng *rand.Rand, target Target, ball Ball) Result {
+	rate := CatchRate(target, ball)
+	if rate >= 255 {
+		return Result{Shakes: 3, Caught: true}
+	}
+	chance := ShakeChance(rate)
+	for check := range 4 {
+		if rng.IntN(65536) >= chance {
+			return Result{Shakes: check}
+		}
+	}
+	return Result{Shakes: 3, Caught: true}
+}
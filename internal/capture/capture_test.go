@@ -0,0 +1,114 @@
+package capture
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCatchRate(t *testing.T) {
+	cases := []struct {
+		name   string
+		target Target
+		ball   Ball
+		want   int
+	}{
+		{name: "full hp pokeball", target: Target{CaptureRate: 30, MaxHP: 100, HP: 100}, ball: PokeBall, want: 10},
+		{name: "one hp is three times full hp", target: Target{CaptureRate: 30, MaxHP: 100, HP: 1}, ball: PokeBall, want: 29},
+		{name: "great ball", target: Target{CaptureRate: 45, MaxHP: 100, HP: 100}, ball: GreatBall, want: 22},
+		{name: "ultra ball", target: Target{CaptureRate: 45, MaxHP: 100, HP: 100}, ball: UltraBall, want: 30},
+		{name: "asleep doubles", target: Target{CaptureRate: 45, MaxHP: 100, HP: 100, Status: Asleep}, ball: PokeBall, want: 30},
+		{name: "paralyzed", target: Target{CaptureRate: 45, MaxHP: 100, HP: 100, Status: Paralyzed}, ball: PokeBall, want: 22},
+		{name: "capped at 255", target: Target{CaptureRate: 255, MaxHP: 100, HP: 1, Status: Frozen}, ball: UltraBall, want: 255},
+		{name: "never below 1", target: Target{CaptureRate: 3, MaxHP: 100, HP: 100}, ball: PokeBall, want: 1},
+		{name: "master ball", target: Target{CaptureRate: 3, MaxHP: 100, HP: 100}, ball: MasterBall, want: 255},
+		{name: "hp out of range is clamped", target: Target{CaptureRate: 30, MaxHP: 100, HP: 500}, ball: PokeBall, want: 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := CatchRate(c.target, c.ball); got != c.want {
+				t.Errorf("expected catch rate %d, received %d", c.want, got)
+			}
+		})
+	}
+}
+
+func TestShakeChance(t *testing.T) {
+	cases := map[int]int{1: 16643, 10: 29958, 100: 52428, 254: 65535, 255: 65536}
+	for rate, want := range cases {
+		if got := ShakeChance(rate); got != want {
+			t.Errorf("rate %d: expected shake chance %d, received %d", rate, want, got)
+		}
+	}
+}
+
+func TestThrowIsRepeatableWithASeed(t *testing.T) {
+	target := Target{CaptureRate: 45, MaxHP: 100, HP: 50, Status: Asleep}
+	first, second := rand.New(rand.NewPCG(1, 2)), rand.New(rand.NewPCG(1, 2))
+	for i := range 50 {
+		a, b := Throw(first, target, GreatBall), Throw(second, target, GreatBall)
+		if a != b {
+			t.Fatalf("throw %d differs with the same seed: %+v and %+v", i, a, b)
+		}
+		if a.Shakes < 0 || a.Shakes > 3 || (a.Caught && a.Shakes != 3) {
+			t.Fatalf("throw %d is impossible: %+v", i, a)
+		}
+	}
+}
+
+func TestThrowMatchesShakeChance(t *testing.T) {
+	rng := rand.New(rand.NewPCG(42, 42))
+	target := Target{CaptureRate: 30, MaxHP: 100, HP: 100}
+	const throws = 20000
+	caught := 0
+	shakes := [4]int{}
+	for range throws {
+		result := Throw(rng, target, PokeBall)
+		if result.Caught {
+			caught++
+			continue
+		}
+		shakes[result.Shakes]++
+	}
+
+	perCheck := float64(ShakeChance(CatchRate(target, PokeBall))) / 65536
+	want := math.Pow(perCheck, 4)
+	if got := float64(caught) / throws; math.Abs(got-want) > 0.01 {
+		t.Errorf("expected about %.3f of throws to catch, %.3f did", want, got)
+	}
+	if got, want := float64(shakes[0])/throws, 1-perCheck; math.Abs(got-want) > 0.02 {
+		t.Errorf("expected about %.3f of throws to break free without a shake, %.3f did", want, got)
+	}
+}
+
+func TestThrowAlwaysCatches(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 7))
+	for range 100 {
+		if result := Throw(rng, Target{CaptureRate: 3, MaxHP: 100, HP: 100}, MasterBall); !result.Caught || result.Shakes != 3 {
+			t.Fatalf("master ball should always catch, received %+v", result)
+		}
+		if result := Throw(rng, Target{CaptureRate: 255, MaxHP: 100, HP: 1, Status: Asleep}, PokeBall); !result.Caught {
+			t.Fatalf("a catch rate of 255 should always catch, received %+v", result)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for raw, want := range map[string]Ball{"poke": PokeBall, "Great": GreatBall, "ultra-ball": UltraBall, " master ": MasterBall} {
+		if got, err := ParseBall(raw); err != nil || got != want {
+			t.Errorf("ParseBall(%q): expected %s, received %s, err %v", raw, want, got, err)
+		}
+	}
+	if _, err := ParseBall("safari"); err == nil {
+		t.Error("expected an error for an unknown ball")
+	}
+	if got, err := ParseStatus("Sleep"); err != nil || got != Asleep {
+		t.Errorf("ParseStatus: expected sleep, received %s, err %v", got, err)
+	}
+	if _, err := ParseStatus("confused"); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+	if PokeBall.String() != "Pokeball" || UltraBall.String() != "Ultra Ball" {
+		t.Errorf("unexpected ball names %s and %s", PokeBall, UltraBall)
+	}
+}
@@ -0,0 +1,157 @@
+package encounter
+
+import (
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const Walk = "walk" //tall grass and caves, what most areas have
+
+// Slot is one way a pokemon can turn up, chance is its weight against every other slot for the same method and version
+type Slot struct {
+	Pokemon  string
+	Chance   int
+	MinLevel int
+	MaxLevel int
+}
+
+// Encounter is the wild pokemon a roll produced
+type Encounter struct {
+	Pokemon string `json:"pokemon"`
+	Level   int    `json:"level"`
+	Method  string `json:"method"`
+	Version string `json:"version"`
+}
+
+// Methods lists how pokemon can be found in area in version, eg walk, surf or old-rod, sorted. An empty version means any game.
+func Methods(area pokeapi.LocationArea, version string) []string {
+	methods := []string{}
+	forEachDetail(area, func(detailVersion, method string, slot Slot) {
+		if (version == "" || detailVersion == version) && !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	})
+	sort.Strings(methods)
+	return methods
+}
+
+// Pokemon lists every pokemon that lives in area in version, in the order PokeAPI gives them. An empty version means any game.
+// Unlike Slots this includes pokemon that only turn up under special conditions, they still live there.
+func Pokemon(area pokeapi.LocationArea, version string) []string {
+	names := []string{}
+	for _, encounter := range area.PokemonEncounters {
+		for _, versionDetail := range encounter.VersionDetails {
+			if version == "" || versionDetail.Version.Name == version {
+				names = append(names, encounter.Pokemon.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// InVersion reports whether area is part of version. Areas with no version data at all, like most towns, can't be told apart so count as in every version.
+func InVersion(area pokeapi.LocationArea, version string) bool {
+	known := false
+	for _, rate := range area.EncounterMethodRates {
+		for _, versionDetail := range rate.VersionDetails {
+			known = true
+			if versionDetail.Version.Name == version {
+				return true
+			}
+		}
+	}
+	for _, encounter := range area.PokemonEncounters {
+		for _, versionDetail := range encounter.VersionDetails {
+			known = true
+			if versionDetail.Version.Name == version {
+				return true
+			}
+		}
+	}
+	return !known
+}
+
+// Versions lists the games that have pokemon in area for method, the one with the most slots first and the rest by name
+func Versions(area pokeapi.LocationArea, method string) []string {
+	counts := make(map[string]int)
+	forEachDetail(area, func(version, detailMethod string, slot Slot) {
+		if detailMethod == method {
+			counts[version]++
+		}
+	})
+	versions := make([]string, 0, len(counts))
+	for version := range counts {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if counts[versions[i]] != counts[versions[j]] {
+			return counts[versions[i]] > counts[versions[j]]
+		}
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+// Slots gathers every slot in area for method in version. Slots that only apply under a condition, like a swarm or
+// the night, are left out since the pokedex has no clock or events, the ones for the usual state (swarm-no, radar-off) are kept.
+func Slots(area pokeapi.LocationArea, method, version string) []Slot {
+	slots := []Slot{}
+	forEachDetail(area, func(detailVersion, detailMethod string, slot Slot) {
+		if detailMethod == method && detailVersion == version {
+			slots = append(slots, slot)
+		}
+	})
+	return slots
+}
+
+// Roll picks a slot weighted by chance and a level between its min and max, for a pokemon found in version by method
+func Roll(rng *rand.Rand, slots []Slot, method, version string) (Encounter, error) {
+	total := 0
+	for _, slot := range slots {
+		total += max(slot.Chance, 0)
+	}
+	if total == 0 {
+		return Encounter{}, fmt.Errorf("no pokemon can be found by %s in %s", method, version)
+	}
+	roll := rng.IntN(total)
+	picked := 0
+	for roll >= max(slots[picked].Chance, 0) {
+		roll -= max(slots[picked].Chance, 0)
+		picked++
+	}
+	slot := slots[picked]
+	level := slot.MinLevel
+	if slot.MaxLevel > slot.MinLevel {
+		level += rng.IntN(slot.MaxLevel - slot.MinLevel + 1)
+	}
+	return Encounter{Pokemon: slot.Pokemon, Level: level, Method: method, Version: version}, nil
+}
+
+func forEachDetail(area pokeapi.LocationArea, visit func(version, method string, slot Slot)) {
+	for _, encounter := range area.PokemonEncounters {
+		for _, versionDetail := range encounter.VersionDetails {
+			for _, detail := range versionDetail.EncounterDetails {
+				if !usualConditions(detail.ConditionValues) {
+					continue
+				}
+				slot := Slot{Pokemon: encounter.Pokemon.Name, Chance: detail.Chance, MinLevel: detail.MinLevel, MaxLevel: detail.MaxLevel}
+				visit(versionDetail.Version.Name, detail.Method.Name, slot)
+			}
+		}
+	}
+}
+
+// usualConditions is true when a slot needs nothing special to happen, ie no conditions or only ones like swarm-no
+func usualConditions(conditions []pokeapi.NamedResource) bool {
+	for _, condition := range conditions {
+		if !strings.HasSuffix(condition.Name, "-no") && !strings.HasSuffix(condition.Name, "-none") && !strings.HasSuffix(condition.Name, "-off") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package encounter
+
+import (
+	"encoding/json"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+// canalave is trimmed from PokeAPI's canalave-city-area, plus a swarm slot and a night slot that should never be rolled
+const canalave = `{
+	"name": "canalave-city-area",
+	"pokemon_encounters": [
+		{"pokemon": {"name": "tentacool"}, "version_details": [
+			{"version": {"name": "diamond"}, "encounter_details": [{"chance": 60, "min_level": 20, "max_level": 30, "method": {"name": "surf"}, "condition_values": []}]},
+			{"version": {"name": "pearl"}, "encounter_details": [{"chance": 60, "min_level": 20, "max_level": 30, "method": {"name": "surf"}, "condition_values": []}]}
+		]},
+		{"pokemon": {"name": "wingull"}, "version_details": [
+			{"version": {"name": "diamond"}, "encounter_details": [{"chance": 30, "min_level": 20, "max_level": 30, "method": {"name": "surf"}, "condition_values": [{"name": "swarm-no"}]}]}
+		]},
+		{"pokemon": {"name": "tentacruel"}, "version_details": [
+			{"version": {"name": "diamond"}, "encounter_details": [{"chance": 10, "min_level": 20, "max_level": 40, "method": {"name": "surf"}, "condition_values": []}]}
+		]},
+		{"pokemon": {"name": "magikarp"}, "version_details": [
+			{"version": {"name": "diamond"}, "encounter_details": [{"chance": 100, "min_level": 3, "max_level": 15, "method": {"name": "old-rod"}, "condition_values": []}]}
+		]},
+		{"pokemon": {"name": "shellos"}, "version_details": [
+			{"version": {"name": "diamond"}, "encounter_details": [{"chance": 50, "min_level": 20, "max_level": 20, "method": {"name": "surf"}, "condition_values": [{"name": "swarm-yes"}]}]},
+			{"version": {"name": "platinum"}, "encounter_details": [{"chance": 50, "min_level": 20, "max_level": 20, "method": {"name": "walk"}, "condition_values": [{"name": "time-night"}]}]}
+		]}
+	]
+}`
+
+func loadArea(t *testing.T) pokeapi.LocationArea {
+	t.Helper()
+	var area pokeapi.LocationArea
+	if err := json.Unmarshal([]byte(canalave), &area); err != nil {
+		t.Fatalf("unexpected error decoding area: %v", err)
+	}
+	return area
+}
+
+func TestMethodsAndVersions(t *testing.T) {
+	area := loadArea(t)
+	if got, want := Methods(area, ""), []string{"old-rod", "surf"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected methods %v, received %v", want, got)
+	}
+	if got, want := Methods(area, "pearl"), []string{"surf"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected pearl methods %v, received %v", want, got)
+	}
+	if got, want := Versions(area, "surf"), []string{"diamond", "pearl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected versions %v, received %v", want, got)
+	}
+	if got := Versions(area, "walk"); len(got) != 0 {
+		t.Errorf("expected no versions for walk, the only walk slot is at night, received %v", got)
+	}
+}
+
+func TestVersionFilters(t *testing.T) {
+	area := loadArea(t)
+	if got, want := Pokemon(area, ""), []string{"tentacool", "wingull", "tentacruel", "magikarp", "shellos"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected every pokemon %v, received %v", want, got)
+	}
+	if got, want := Pokemon(area, "platinum"), []string{"shellos"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected platinum pokemon %v, received %v", want, got)
+	}
+	if !InVersion(area, "pearl") || InVersion(area, "heartgold") {
+		t.Error("expected canalave to be in pearl and not in heartgold")
+	}
+	if !InVersion(pokeapi.LocationArea{Name: "eterna-city-area"}, "heartgold") {
+		t.Error("expected an area without version data to count as in every version")
+	}
+}
+
+func TestSlots(t *testing.T) {
+	area := loadArea(t)
+	want := []Slot{
+		{Pokemon: "tentacool", Chance: 60, MinLevel: 20, MaxLevel: 30},
+		{Pokemon: "wingull", Chance: 30, MinLevel: 20, MaxLevel: 30},
+		{Pokemon: "tentacruel", Chance: 10, MinLevel: 20, MaxLevel: 40},
+	}
+	if got := Slots(area, "surf", "diamond"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected slots %+v, received %+v", want, got)
+	}
+}
+
+func TestRollIsWeightedByChance(t *testing.T) {
+	slots := Slots(loadArea(t), "surf", "diamond")
+	rng := rand.New(rand.NewPCG(3, 3))
+	const rolls = 20000
+	counts := make(map[string]int)
+	for range rolls {
+		found, err := Roll(rng, slots, "surf", "diamond")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, slot := range slots {
+			if slot.Pokemon == found.Pokemon && (found.Level < slot.MinLevel || found.Level > slot.MaxLevel) {
+				t.Fatalf("%s rolled at level %d, outside %d-%d", found.Pokemon, found.Level, slot.MinLevel, slot.MaxLevel)
+			}
+		}
+		counts[found.Pokemon]++
+	}
+	for _, slot := range slots {
+		want := float64(slot.Chance) / 100
+		if got := float64(counts[slot.Pokemon]) / rolls; math.Abs(got-want) > 0.02 {
+			t.Errorf("expected %s about %.2f of the time, received %.2f", slot.Pokemon, want, got)
+		}
+	}
+}
+
+func TestRollIsRepeatableWithASeed(t *testing.T) {
+	slots := Slots(loadArea(t), "old-rod", "diamond")
+	first, second := rand.New(rand.NewPCG(9, 9)), rand.New(rand.NewPCG(9, 9))
+	for range 20 {
+		a, _ := Roll(first, slots, "old-rod", "diamond")
+		b, _ := Roll(second, slots, "old-rod", "diamond")
+		if a != b {
+			t.Fatalf("rolls differ with the same seed: %+v and %+v", a, b)
+		}
+	}
+}
+
+func TestRollWithNothingToFind(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	if _, err := Roll(rng, nil, "surf", "pearl"); err == nil {
+		t.Error("expected an error with no slots")
+	}
+	if _, err := Roll(rng, []Slot{{Pokemon: "magikarp", Chance: 0}}, "old-rod", "pearl"); err == nil {
+		t.Error("expected an error when every slot has no chance")
+	}
+}
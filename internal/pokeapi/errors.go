@@ -0,0 +1,57 @@
+package pokeapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrServer        = errors.New("server error")
+	ErrDecode        = errors.New("could not decode response")
+	ErrNetwork       = errors.New("request failed")              //connection problems and timeouts, no status code
+	ErrStatus        = errors.New("unexpected status")           //any other non 2xx response
+	ErrNotInSnapshot = errors.New("not in the offline snapshot") //offline, so there is no telling if it exists at all
+)
+
+// RequestError carries the URL and status code of a failed call. Kind is one of the Err values above so callers can use errors.Is.
+type RequestError struct {
+	URL        string
+	StatusCode int //0 when the failure happened before or after the HTTP exchange, eg decoding a cached value
+	Kind       error
+	Err        error //underlying cause, if any
+}
+
+func (e *RequestError) Error() string {
+	msg := e.Kind.Error()
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (%d %s)", msg, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	msg += " from " + e.URL
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *RequestError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func statusError(url string, statusCode int) error {
+	kind := ErrStatus
+	switch {
+	case statusCode == http.StatusNotFound:
+		kind = ErrNotFound
+	case statusCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case statusCode >= 500:
+		kind = ErrServer
+	}
+	return &RequestError{URL: url, StatusCode: statusCode, Kind: kind}
+}
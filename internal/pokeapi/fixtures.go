@@ -0,0 +1,152 @@
+package pokeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/atomicfile"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrUnrecorded = errors.New("no recorded fixture") //the replayer was asked for something that was never recorded
+
+// Fixture is one recorded request and the response PokeAPI gave it, stored as <dir>/<FixtureName>.json
+type Fixture struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Status int             `json:"status"`
+	Header http.Header     `json:"header,omitempty"` //only the headers the client looks at
+	Body   json.RawMessage `json:"body,omitempty"`   //kept as json when it is json, so fixtures stay readable and diffable
+	Text   string          `json:"text,omitempty"`   //the body when it isn't json, eg a 404 page
+}
+
+var keptHeaders = []string{"Content-Type", "Retry-After"}
+
+var unsafeFixtureChars = regexp.MustCompile(`[^a-zA-Z0-9=&.-]+`)
+
+// FixtureName is the file a request is stored under. Only the method, path and query are used, so fixtures recorded
+// against the public API replay for any base URL with the same path.
+func FixtureName(req *http.Request) string {
+	name := req.Method + "_" + strings.Trim(req.URL.Path, "/")
+	if req.URL.RawQuery != "" {
+		name += "_" + req.URL.RawQuery
+	}
+	return strings.Trim(unsafeFixtureChars.ReplaceAllString(name, "_"), "_")
+}
+
+// Recorder passes requests through to next and writes every request/response pair to dir, overwriting older recordings
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// NewRecorder records through next, nil uses http.DefaultTransport
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err //nothing to replay for a request that never got an answer
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := Fixture{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: http.Header{}}
+	for _, name := range keptHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			fixture.Header.Set(name, value)
+		}
+	}
+	if json.Valid(body) {
+		fixture.Body = body
+	} else {
+		fixture.Text = string(body)
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := atomicfile.Write(filepath.Join(r.dir, FixtureName(req)+".json"), append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("recording %s: %w", req.URL, err)
+	}
+	return resp, nil
+}
+
+// Replayer answers requests from fixtures written by a Recorder and never touches the network.
+// A request with no fixture fails with ErrUnrecorded and is remembered, so a test can fail even if the code under test swallows the error.
+type Replayer struct {
+	dir        string
+	mu         sync.Mutex
+	unrecorded map[string]bool
+}
+
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir, unrecorded: make(map[string]bool)}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := FixtureName(req)
+	data, err := os.ReadFile(filepath.Join(r.dir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		r.mu.Lock()
+		r.unrecorded[req.Method+" "+req.URL.String()] = true
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s, expected %s.json in %s", ErrUnrecorded, req.Method, req.URL, name, r.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("fixture %s.json: %w", name, err)
+	}
+	body := []byte(fixture.Body)
+	if fixture.Body == nil {
+		body = []byte(fixture.Text)
+	}
+	header := fixture.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Unrecorded lists every request that had no fixture, sorted
+func (r *Replayer) Unrecorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests := make([]string, 0, len(r.unrecorded))
+	for request := range r.unrecorded {
+		requests = append(requests, request)
+	}
+	sort.Strings(requests)
+	return requests
+}
@@ -1,37 +1,168 @@
 package pokeapi
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
 	"github.com/CSelvidge/pokedexcli/internal/pokecache"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
-func GenericURLCaller(url string, cache *pokecache.Cache, target interface{}) error { //generic function to fill different types of structs
+const (
+	DefaultBaseURL   = "https://pokeapi.co/api/v2"
+	LocationPageSize = 20 //same as PokeAPI's own default page size
+)
 
-	val, exists := cache.Get(url) // check that cache first!
-	if exists {
-		if err := json.Unmarshal(val, target); err != nil {
-			return err
+type ClientConfig struct {
+	BaseURL    string       //empty uses DefaultBaseURL, point this at a mirror to avoid the public API
+	HTTPClient *http.Client //nil uses a client with a 15 second timeout
+	Retry      RetryPolicy  //zero value means no retries
+	Cache      *pokecache.Cache
+	Logger     *slog.Logger //nil discards logs
+	Source     Fetcher      //where responses come from, nil fetches them over HTTP with the settings above
+}
+
+// Fetcher returns the raw body for a PokeAPI URL. *Requester fetches over HTTP and *Snapshot reads from disk for offline use.
+type Fetcher interface {
+	Get(ctx context.Context, url string) ([]byte, error)
+}
+
+// Client is the only thing that talks to PokeAPI, every response goes through its cache
+type Client struct {
+	baseURL string
+	source  Fetcher
+	cache   *pokecache.Cache
+	logger  *slog.Logger
+}
+
+func NewClient(cfg ClientConfig) *Client {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	source := cfg.Source
+	if source == nil {
+		requester := NewRequester(15*time.Second, cfg.Retry)
+		if cfg.HTTPClient != nil {
+			requester.HTTPClient = cfg.HTTPClient
 		}
-		return nil
+		source = requester
 	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return &Client{
+		baseURL: baseURL,
+		source:  source,
+		cache:   cfg.Cache,
+		logger:  logger,
+	}
+}
+
+// ListLocationAreas fetches one page of location areas, pages start at 0
+func (c *Client) ListLocationAreas(ctx context.Context, page int) (LocationAreaList, error) {
+	var list LocationAreaList
+	if page < 0 {
+		return list, fmt.Errorf("page %d is out of range", page)
+	}
+	pageURL := fmt.Sprintf("%s/location-area?offset=%d&limit=%d", c.baseURL, page*LocationPageSize, LocationPageSize) //offset first so cache ttl rules can match on it
+	err := c.get(ctx, pageURL, &list)
+	return list, err
+}
+
+func (c *Client) GetLocationArea(ctx context.Context, name string) (LocationArea, error) {
+	var area LocationArea
+	err := c.get(ctx, c.LocationAreaURL(name), &area)
+	return area, err
+}
+
+func (c *Client) GetPokemon(ctx context.Context, name string) (actors.Pokemon, error) {
+	var pokemon actors.Pokemon
+	err := c.get(ctx, c.baseURL+"/pokemon/"+url.PathEscape(name), &pokemon)
+	return pokemon, err
+}
+
+func (c *Client) GetPokemonSpecies(ctx context.Context, name string) (PokemonSpecies, error) {
+	var species PokemonSpecies
+	err := c.get(ctx, c.baseURL+"/pokemon-species/"+url.PathEscape(name), &species)
+	return species, err
+}
+
+func (c *Client) GetVersion(ctx context.Context, name string) (Version, error) {
+	var version Version
+	err := c.get(ctx, c.baseURL+"/version/"+url.PathEscape(name), &version)
+	return version, err
+}
 
-	resp, err := http.Get(url)
+func (c *Client) GetVersionGroup(ctx context.Context, name string) (VersionGroup, error) {
+	var group VersionGroup
+	err := c.get(ctx, c.baseURL+"/version-group/"+url.PathEscape(name), &group)
+	return group, err
+}
+
+func (c *Client) GetRegion(ctx context.Context, name string) (Region, error) {
+	var region Region
+	err := c.get(ctx, c.baseURL+"/region/"+url.PathEscape(name), &region)
+	return region, err
+}
+
+func (c *Client) GetLocation(ctx context.Context, name string) (Location, error) {
+	var location Location
+	err := c.get(ctx, c.baseURL+"/location/"+url.PathEscape(name), &location)
+	return location, err
+}
+
+// Download fetches resource, eg "pokemon/chansey", skipping the cache so the full response is kept, and saves it to snapshot.
+// The body is returned too so callers can follow links in it without a second request.
+func (c *Client) Download(ctx context.Context, snapshot *Snapshot, resource string) ([]byte, error) {
+	resourceURL := c.baseURL + "/" + strings.Trim(resource, "/")
+	c.logger.Debug("downloading", "url", resourceURL)
+	body, err := c.source.Get(ctx, resourceURL)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !json.Valid(body) {
+		return nil, &RequestError{URL: resourceURL, StatusCode: http.StatusOK, Kind: ErrDecode, Err: fmt.Errorf("response is not json")}
 	}
-	defer resp.Body.Close()
+	if err := snapshot.Save(resource, body); err != nil {
+		return nil, fmt.Errorf("saving %s to the snapshot: %w", resource, err)
+	}
+	return body, nil
+}
 
-	dec := json.NewDecoder(resp.Body)
-	if err := dec.Decode(target); err != nil {
-		return err
+// LocationAreaURL is also the cache key for the area, which lets callers peek at cached data without another request
+func (c *Client) LocationAreaURL(name string) string {
+	return c.baseURL + "/location-area/" + url.PathEscape(name)
+}
+
+func (c *Client) get(ctx context.Context, url string, target any) error {
+	val, exists := c.cache.Get(url) // check that cache first!
+	if exists {
+		c.logger.Debug("cache hit", "url", url)
+		if err := json.Unmarshal(val, target); err != nil {
+			return &RequestError{URL: url, Kind: ErrDecode, Err: err}
+		}
+		return nil
 	}
 
-	data, err := json.Marshal(target)
+	c.logger.Debug("fetching", "url", url)
+	body, err := c.source.Get(ctx, url) //only ever returns 2xx bodies, so error pages never reach the cache
 	if err != nil {
+		c.logger.Debug("request failed", "url", url, "error", err)
 		return err
 	}
 
-	cache.Add(url, data)
+	if err := json.Unmarshal(body, target); err != nil {
+		return &RequestError{URL: url, StatusCode: http.StatusOK, Kind: ErrDecode, Err: err}
+	}
+
+	c.cache.Add(url, body) //the raw body, not target, so a field added to a type later is still there on a cache hit
 	return nil
 }
@@ -0,0 +1,393 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CSelvidge/pokedexcli/internal/pokecache"
+)
+
+func newTestClient(t *testing.T, baseURL string) (*Client, *pokecache.Cache) {
+	t.Helper()
+	cache, err := pokecache.NewCache("minute", 5)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+	t.Cleanup(cache.Close)
+	client := NewClient(ClientConfig{BaseURL: baseURL, Cache: cache})
+	client.source.(*Requester).sleep = func(context.Context, time.Duration) error { return nil }
+	return client, cache
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/location-area/ok":
+			w.Write([]byte(`{"name": "canalave-city-area"}`))
+		case "/location-area/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/location-area/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/location-area/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		case "/location-area/garbage":
+			w.Write([]byte("Not JSON"))
+		default:
+			http.Error(w, "Not Found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name       string
+		wantErr    error
+		wantStatus int
+	}{
+		{name: "ok"},
+		{name: "not-a-place", wantErr: ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "limited", wantErr: ErrRateLimited, wantStatus: http.StatusTooManyRequests},
+		{name: "broken", wantErr: ErrServer, wantStatus: http.StatusBadGateway},
+		{name: "teapot", wantErr: ErrStatus, wantStatus: http.StatusTeapot},
+		{name: "garbage", wantErr: ErrDecode, wantStatus: http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client, cache := newTestClient(t, server.URL)
+			url := client.LocationAreaURL(c.name)
+			area, err := client.GetLocationArea(context.Background(), c.name)
+
+			_, cached := cache.Get(url)
+			if c.wantErr == nil {
+				if err != nil || area.Name != "canalave-city-area" || !cached {
+					t.Errorf("expected decoded and cached response, got err %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, c.wantErr) {
+				t.Errorf("expected %v, got %v", c.wantErr, err)
+			}
+			var reqErr *RequestError
+			if !errors.As(err, &reqErr) || reqErr.StatusCode != c.wantStatus || reqErr.URL != url {
+				t.Errorf("expected RequestError with status %d for %s, got %#v", c.wantStatus, url, err)
+			}
+			if cached {
+				t.Errorf("failed responses must not be cached")
+			}
+		})
+	}
+}
+
+func TestClientTypedMethods(t *testing.T) {
+	const pikachu = `{"name": "pikachu", "base_experience": 112, "height": 4}` //height isn't decoded anywhere, it should still be cached
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case r.URL.Path == "/location-area" && r.URL.Query().Get("offset") == "20":
+			w.Write([]byte(`{"count": 40, "previous": "prev", "results": [{"name": "canalave-city-area"}]}`))
+		case r.URL.Path == "/pokemon/pikachu":
+			w.Write([]byte(pikachu))
+		default:
+			http.Error(w, "Not Found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, cache := newTestClient(t, server.URL+"/")
+	list, err := client.ListLocationAreas(context.Background(), 1)
+	if err != nil || len(list.Results) != 1 || list.Results[0].Name != "canalave-city-area" || list.Next != "" {
+		t.Errorf("unexpected page: %+v, err %v", list, err)
+	}
+	if _, err := client.ListLocationAreas(context.Background(), -1); err == nil {
+		t.Errorf("expected error for a negative page")
+	}
+
+	for i := 0; i < 2; i++ {
+		pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+		if err != nil || pokemon.Name != "pikachu" || pokemon.BaseExperience != 112 {
+			t.Errorf("unexpected pokemon: %+v, err %v", pokemon, err)
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected the second GetPokemon to come from the cache, got %d requests", got)
+	}
+	if cached, _ := cache.Get(server.URL + "/pokemon/pikachu"); string(cached) != pikachu {
+		t.Errorf("expected the raw response to be cached, got %s", cached)
+	}
+}
+
+// testRequester never really sleeps, so retry tests stay fast
+func testRequester(timeout time.Duration, attempts int) *Requester {
+	r := NewRequester(timeout, RetryPolicy{MaxAttempts: attempts, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+	r.sleep = func(context.Context, time.Duration) error { return nil }
+	return r
+}
+
+func TestRequesterRetries(t *testing.T) {
+	cases := []struct {
+		name         string
+		failures     int //how many requests fail before the server recovers
+		failStatus   int
+		retryAfter   string
+		wantErr      error
+		wantAttempts int32
+		wantMinSleep time.Duration
+	}{
+		{name: "recovers from 502", failures: 2, failStatus: http.StatusBadGateway, wantAttempts: 3},
+		{name: "gives up after max attempts", failures: 10, failStatus: http.StatusServiceUnavailable, wantErr: ErrServer, wantAttempts: 4},
+		{name: "honors retry-after", failures: 1, failStatus: http.StatusTooManyRequests, retryAfter: "1", wantAttempts: 2, wantMinSleep: time.Second},
+		{name: "gives up on a long retry-after", failures: 10, failStatus: http.StatusTooManyRequests, retryAfter: "3600", wantErr: ErrRateLimited, wantAttempts: 1},
+		{name: "does not retry 404", failures: 10, failStatus: http.StatusNotFound, wantErr: ErrNotFound, wantAttempts: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(attempts.Add(1)) <= c.failures {
+					if c.retryAfter != "" {
+						w.Header().Set("Retry-After", c.retryAfter)
+					}
+					w.WriteHeader(c.failStatus)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			r := testRequester(time.Second, 4)
+			var slept []time.Duration
+			r.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			_, err := r.Get(context.Background(), server.URL)
+			if c.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if c.wantErr != nil && !errors.Is(err, c.wantErr) {
+				t.Errorf("expected %v, got %v", c.wantErr, err)
+			}
+			if got := attempts.Load(); got != c.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", c.wantAttempts, got)
+			}
+			for i, d := range slept {
+				if d > time.Second && c.wantMinSleep == 0 {
+					t.Errorf("backoff %d waited %v, more than the max delay", i, d)
+				}
+			}
+			if c.wantMinSleep > 0 && (len(slept) == 0 || slept[0] < c.wantMinSleep) {
+				t.Errorf("expected to wait at least %v, waited %v", c.wantMinSleep, slept)
+			}
+		})
+	}
+}
+
+func TestRequesterTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	r := testRequester(50*time.Millisecond, 2)
+	_, err := r.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("expected ErrNetwork for a hung server, got %v", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("expected timeouts to be retried, got %d attempts", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		full := policy.BaseDelay << attempt
+		if full > policy.MaxDelay {
+			full = policy.MaxDelay
+		}
+		d := policy.delay(attempt, 0)
+		if d < full/2 || d > full {
+			t.Errorf("attempt %d: expected delay between %v and %v, got %v", attempt, full/2, full, d)
+		}
+	}
+	if d := policy.delay(0, 800*time.Millisecond); d != 800*time.Millisecond {
+		t.Errorf("expected Retry-After to win, got %v", d)
+	}
+}
+
+func TestRequesterCancel(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		<-r.Context().Done() //hang until the client gives up
+	}))
+	defer server.Close()
+
+	r := NewRequester(time.Minute, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := r.Get(ctx, server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancel took %v to stop the request", elapsed)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected a cancelled request not to be retried, got %d attempts", got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := NewSnapshot(dir)
+	for _, name := range []string{"eterna-city-area", "canalave-city-area", "trophy-garden-area"} {
+		if err := snapshot.Save("location-area/"+name, []byte(`{"name": "`+name+`"}`)); err != nil {
+			t.Fatalf("unexpected error saving %s: %v", name, err)
+		}
+	}
+	if err := snapshot.Save("location-area/canalave-city-area", []byte(`{"name": "canalave-city-area"}`)); err != nil {
+		t.Fatalf("unexpected error saving again: %v", err)
+	}
+	if err := snapshot.Save("pokemon", []byte(`{}`)); err == nil {
+		t.Errorf("expected a resource without a name to be rejected")
+	}
+
+	//api-data lays pokemon out by id, only the index knows the names
+	if err := writeFile(filepath.Join(dir, "api", "v2", "pokemon", "index.json"), `{"count": 1, "results": [{"name": "chansey", "url": "/api/v2/pokemon/113/"}]}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(dir, "api", "v2", "pokemon", "113", "index.json"), `{"name": "chansey", "base_experience": 395}`); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := pokecache.NewCache("minute", 5)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+	t.Cleanup(cache.Close)
+	client := NewClient(ClientConfig{Cache: cache, Source: snapshot})
+	ctx := context.Background()
+
+	first, err := client.ListLocationAreas(ctx, 0)
+	if err != nil || first.Count != 3 || len(first.Results) != 3 || first.Next != "" || first.Previous != "" {
+		t.Errorf("unexpected first page: %+v, err %v", first, err)
+	}
+	if first.Results[0].Name != "canalave-city-area" {
+		t.Errorf("expected the index to be sorted by name, received %+v", first.Results)
+	}
+	if area, err := client.GetLocationArea(ctx, "trophy-garden-area"); err != nil || area.Name != "trophy-garden-area" {
+		t.Errorf("unexpected area: %+v, err %v", area, err)
+	}
+	if pokemon, err := client.GetPokemon(ctx, "chansey"); err != nil || pokemon.BaseExperience != 395 {
+		t.Errorf("expected chansey to be found by id, received %+v, err %v", pokemon, err)
+	}
+	if _, err := client.GetPokemon(ctx, "pikachu"); !errors.Is(err, ErrNotInSnapshot) {
+		t.Errorf("expected ErrNotInSnapshot, received %v", err)
+	}
+	if _, err := client.GetRegion(ctx, "sinnoh"); !errors.Is(err, ErrNotInSnapshot) {
+		t.Errorf("expected ErrNotInSnapshot for a resource with no index, received %v", err)
+	}
+
+	body, err := snapshot.Get(ctx, "https://pokeapi.co/api/v2/location-area?offset=1&limit=1")
+	if err != nil {
+		t.Fatalf("unexpected error paging: %v", err)
+	}
+	var page LocationAreaList
+	if err := json.Unmarshal(body, &page); err != nil {
+		t.Fatalf("unexpected error decoding page: %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].Name != "eterna-city-area" ||
+		page.Next != "https://pokeapi.co/api/v2/location-area?offset=2&limit=1" ||
+		page.Previous != "https://pokeapi.co/api/v2/location-area?offset=0&limit=1" {
+		t.Errorf("unexpected middle page: %+v", page)
+	}
+}
+
+func writeFile(path, contents string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(contents), 0o644)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/pokemon/chansey":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name": "chansey", "base_experience": 395}`))
+		case "/api/v2/location-area":
+			w.Write([]byte(`{"count": 1, "results": [{"name": "trophy-garden-area"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	fixtures := t.TempDir()
+	recording := NewClient(ClientConfig{BaseURL: server.URL + "/api/v2", HTTPClient: &http.Client{Transport: NewRecorder(fixtures, nil)}, Cache: newCache(t)})
+	ctx := context.Background()
+	if _, err := recording.GetPokemon(ctx, "chansey"); err != nil {
+		t.Fatalf("unexpected error recording: %v", err)
+	}
+	if _, err := recording.ListLocationAreas(ctx, 0); err != nil {
+		t.Fatalf("unexpected error recording: %v", err)
+	}
+	if _, err := recording.GetPokemon(ctx, "missingno"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the 404 to pass through while recording, received %v", err)
+	}
+	server.Close() //everything from here on has to come from the fixtures
+
+	replayer := NewReplayer(fixtures)
+	replaying := NewClient(ClientConfig{BaseURL: "https://pokeapi.co/api/v2", HTTPClient: &http.Client{Transport: replayer}, Cache: newCache(t)})
+	if pokemon, err := replaying.GetPokemon(ctx, "chansey"); err != nil || pokemon.BaseExperience != 395 {
+		t.Errorf("unexpected replayed pokemon: %+v, err %v", pokemon, err)
+	}
+	if list, err := replaying.ListLocationAreas(ctx, 0); err != nil || len(list.Results) != 1 {
+		t.Errorf("unexpected replayed page: %+v, err %v", list, err)
+	}
+	if _, err := replaying.GetPokemon(ctx, "missingno"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the recorded 404 to replay, received %v", err)
+	}
+	if len(replayer.Unrecorded()) != 0 {
+		t.Errorf("expected every request to have a fixture, missing %v", replayer.Unrecorded())
+	}
+
+	_, err := replaying.GetPokemon(ctx, "pikachu")
+	if !errors.Is(err, ErrUnrecorded) {
+		t.Errorf("expected ErrUnrecorded, received %v", err)
+	}
+	if missing := replayer.Unrecorded(); len(missing) != 1 || missing[0] != "GET https://pokeapi.co/api/v2/pokemon/pikachu" {
+		t.Errorf("expected pikachu to be reported as unrecorded, received %v", missing)
+	}
+}
+
+func newCache(t *testing.T) *pokecache.Cache {
+	t.Helper()
+	cache, err := pokecache.NewCache("minute", 5)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+	t.Cleanup(cache.Close)
+	return cache
+}
@@ -0,0 +1,135 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int           //total tries including the first, 1 disables retries
+	BaseDelay   time.Duration //delay before the first retry, doubled each time after
+	MaxDelay    time.Duration //no retry waits longer than this, a Retry-After past it gives up instead
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   250 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// Requester does the raw HTTP side of a call: timeouts, retries and status checks. It knows nothing about the cache or the response shape.
+type Requester struct {
+	HTTPClient *http.Client
+	Retry      RetryPolicy
+	sleep      func(context.Context, time.Duration) error //swapped out in tests so backoff doesn't slow them down
+}
+
+func NewRequester(timeout time.Duration, retry RetryPolicy) *Requester {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	return &Requester{
+		HTTPClient: &http.Client{Timeout: timeout}, //covers connecting, headers and reading the body
+		Retry:      retry,
+		sleep:      sleepContext,
+	}
+}
+
+// sleepContext waits for d, or returns early with the context error if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Get returns the body of a 2xx response, retrying network errors, 429s and 5xxs with jittered exponential backoff.
+// A Retry-After longer than the policy's MaxDelay returns the error straight away.
+// Cancelling ctx stops the request in flight and any backoff wait.
+func (r *Requester) Get(ctx context.Context, url string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt < r.Retry.MaxAttempts; attempt++ {
+		body, retryAfter, err := r.try(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !retryable(err) || attempt == r.Retry.MaxAttempts-1 {
+			break
+		}
+		if retryAfter > r.Retry.MaxDelay { //the server wants a longer break than the policy allows, waiting would just look like a hang
+			break
+		}
+		if err := r.sleep(ctx, r.Retry.delay(attempt, retryAfter)); err != nil {
+			return nil, &RequestError{URL: url, Kind: ErrNetwork, Err: err}
+		}
+	}
+	return nil, lastErr
+}
+
+func (r *Requester) try(ctx context.Context, url string) ([]byte, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, &RequestError{URL: url, Kind: ErrNetwork, Err: err}
+	}
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, &RequestError{URL: url, Kind: ErrNetwork, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body) //drain so the connection can be reused
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), statusError(url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, &RequestError{URL: url, StatusCode: resp.StatusCode, Kind: ErrNetwork, Err: err}
+	}
+	return body, 0, nil
+}
+
+func retryable(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrServer) || errors.Is(err, ErrRateLimited)
+}
+
+// delay picks a random wait between half and all of the capped exponential backoff, the server's Retry-After wins if it is longer
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	backoff := p.BaseDelay << attempt
+	if backoff > p.MaxDelay || backoff <= 0 { //<= 0 catches the shift overflowing
+		backoff = p.MaxDelay
+	}
+	if backoff > 0 {
+		backoff = backoff/2 + rand.N(backoff/2+1)
+	}
+	if retryAfter > backoff {
+		return retryAfter
+	}
+	return backoff
+}
+
+// parseRetryAfter handles both forms of the header, a number of seconds or an HTTP date
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
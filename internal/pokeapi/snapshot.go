@@ -0,0 +1,181 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/atomicfile"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Snapshot serves PokeAPI responses from a directory instead of the network. The layout is the API's own path with
+// an index.json at the end, dir/api/v2/pokemon/chansey/index.json, the same layout as PokeAPI's api-data repo.
+// api-data names its folders by id rather than name, so a name that has no folder is looked up in the resource's index.json.
+type Snapshot struct {
+	dir string
+	mu  sync.Mutex //index.json files are read, merged and rewritten by Save
+}
+
+func NewSnapshot(dir string) *Snapshot {
+	return &Snapshot{dir: dir}
+}
+
+func (s *Snapshot) Dir() string {
+	return s.dir
+}
+
+// Get returns the stored response for rawURL, only the part of the path after /api/v2 is used so any base URL works.
+// Listing a resource, eg /location-area?offset=20&limit=20, pages through its index.json the way the API would.
+func (s *Snapshot) Get(ctx context.Context, rawURL string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &RequestError{URL: rawURL, Kind: ErrNetwork, Err: err}
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, &RequestError{URL: rawURL, Kind: ErrNetwork, Err: err}
+	}
+	resource := resourcePath(parsed.Path)
+	kind, name, isItem := strings.Cut(resource, "/")
+
+	if !isItem {
+		return s.page(rawURL, kind, parsed.Query())
+	}
+	body, err := os.ReadFile(s.indexFile(resource))
+	if errors.Is(err, os.ErrNotExist) {
+		body, err = s.readByID(kind, name)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &RequestError{URL: rawURL, Kind: ErrNotInSnapshot}
+	}
+	if err != nil {
+		return nil, &RequestError{URL: rawURL, Kind: ErrNetwork, Err: err}
+	}
+	return body, nil
+}
+
+// Save stores body as the response for resource, eg "pokemon/chansey", and adds it to the resource's index.json so it can be listed
+func (s *Snapshot) Save(resource string, body []byte) error {
+	resource = strings.Trim(resource, "/")
+	kind, name, isItem := strings.Cut(resource, "/")
+	if !isItem || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("cannot save %q, expected <resource>/<name>", resource)
+	}
+	if err := atomicfile.Write(s.indexFile(resource), body); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, err := s.readIndex(kind)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, entry := range index.Results {
+		if entry.Name == name {
+			return nil
+		}
+	}
+	index.Results = append(index.Results, NamedResource{Name: name, URL: "/api/v2/" + resource + "/"})
+	sort.Slice(index.Results, func(i, j int) bool { return index.Results[i].Name < index.Results[j].Name })
+	index.Count = len(index.Results)
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(s.indexFile(kind), data)
+}
+
+func (s *Snapshot) page(rawURL, kind string, query url.Values) ([]byte, error) {
+	index, err := s.readIndex(kind)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &RequestError{URL: rawURL, Kind: ErrNotInSnapshot}
+	}
+	if err != nil {
+		return nil, &RequestError{URL: rawURL, Kind: ErrDecode, Err: err}
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = LocationPageSize
+	}
+	offset = max(0, min(offset, len(index.Results)))
+	end := min(offset+limit, len(index.Results))
+
+	page := resourceList{Count: len(index.Results), Results: index.Results[offset:end]}
+	if end < len(index.Results) {
+		page.Next = pageURL(rawURL, end, limit)
+	}
+	if offset > 0 {
+		page.Previous = pageURL(rawURL, max(0, offset-limit), limit)
+	}
+	return json.Marshal(page)
+}
+
+// readByID finds name in the resource's index.json and reads the folder its url points at, for snapshots that are laid out by id
+func (s *Snapshot) readByID(kind, name string) ([]byte, error) {
+	index, err := s.readIndex(kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range index.Results {
+		if entry.Name != name {
+			continue
+		}
+		id := path.Base(strings.TrimSuffix(entry.URL, "/"))
+		if id == name || id == "." || id == "/" {
+			break
+		}
+		return os.ReadFile(s.indexFile(kind + "/" + id))
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Snapshot) readIndex(kind string) (resourceList, error) {
+	var index resourceList
+	data, err := os.ReadFile(s.indexFile(kind))
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("%s: %w", s.indexFile(kind), err)
+	}
+	return index, nil
+}
+
+func (s *Snapshot) indexFile(resource string) string {
+	return filepath.Join(s.dir, "api", "v2", filepath.FromSlash(resource), "index.json")
+}
+
+// resourcePath turns /api/v2/pokemon/chansey/ into pokemon/chansey, paths without /api/v2 are used as they are
+func resourcePath(urlPath string) string {
+	if _, after, found := strings.Cut(urlPath, "/api/v2/"); found {
+		urlPath = after
+	}
+	return strings.Trim(urlPath, "/")
+}
+
+func pageURL(rawURL string, offset, limit int) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	parsed.RawQuery = fmt.Sprintf("offset=%d&limit=%d", offset, limit)
+	return parsed.String()
+}
+
+// resourceList is the shape of every PokeAPI listing, and of api-data's index.json files
+type resourceList struct {
+	Count    int             `json:"count"`
+	Next     string          `json:"next"`
+	Previous string          `json:"previous"`
+	Results  []NamedResource `json:"results"`
+}
@@ -0,0 +1,99 @@
+package pokeapi
+
+type NamedResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type Region struct {
+	Name          string          `json:"name"`
+	Locations     []NamedResource `json:"locations"`
+	VersionGroups []NamedResource `json:"version_groups"` //the games set in the region, eg diamond-pearl and platinum for sinnoh
+}
+
+type Location struct {
+	Name   string          `json:"name"`
+	Region NamedResource   `json:"region"`
+	Areas  []NamedResource `json:"areas"`
+}
+
+type Version struct {
+	Name         string        `json:"name"`
+	VersionGroup NamedResource `json:"version_group"` //moves are listed per version group, eg diamond-pearl
+}
+
+type VersionGroup struct {
+	Name     string          `json:"name"`
+	Regions  []NamedResource `json:"regions"`
+	Versions []NamedResource `json:"versions"`
+}
+
+type PokemonSpecies struct {
+	Name        string        `json:"name"`
+	CaptureRate int           `json:"capture_rate"` //3 for legendaries up to 255, higher is easier to catch
+	GenderRate  int           `json:"gender_rate"`  //chance of being female in eighths, -1 for genderless
+	GrowthRate  NamedResource `json:"growth_rate"`  //how much XP each level takes, eg medium-slow
+}
+
+type LocationAreaList struct {
+	Count    int    `json:"count"`
+	Next     string `json:"next"`
+	Previous string `json:"previous"`
+	Results  []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"results"`
+}
+
+type LocationArea struct {
+	EncounterMethodRates []struct {
+		EncounterMethod struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"encounter_method"`
+		VersionDetails []struct {
+			Rate    int `json:"rate"`
+			Version struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"version"`
+		} `json:"version_details"`
+	} `json:"encounter_method_rates"`
+	GameIndex int `json:"game_index"`
+	ID        int `json:"id"`
+	Location  struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"location"`
+	Name  string `json:"name"`
+	Names []struct {
+		Language struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"language"`
+		Name string `json:"name"`
+	} `json:"names"`
+	PokemonEncounters []struct {
+		Pokemon struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"pokemon"`
+		VersionDetails []struct {
+			EncounterDetails []struct {
+				Chance          int             `json:"chance"`
+				ConditionValues []NamedResource `json:"condition_values"` //eg time-night or swarm-yes, empty when the slot always applies
+				MaxLevel        int             `json:"max_level"`
+				Method          struct {
+					Name string `json:"name"`
+					URL  string `json:"url"`
+				} `json:"method"`
+				MinLevel int `json:"min_level"`
+			} `json:"encounter_details"`
+			MaxChance int `json:"max_chance"`
+			Version   struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"version"`
+		} `json:"version_details"`
+	} `json:"pokemon_encounters"`
+}
@@ -0,0 +1,98 @@
+package pokecache
+
+import (
+	"strings"
+	"time"
+)
+
+// TTLPolicy picks how long a key should live, returning 0 falls back to the cache lifetime
+type TTLPolicy func(key string) time.Duration
+
+type TTLRule struct {
+	Pattern string //matched as a substring of the key, eg "/pokemon/"
+	TTL     time.Duration
+}
+
+// NewPatternPolicy returns a policy that uses the TTL of the first rule whose pattern appears in the key
+func NewPatternPolicy(rules ...TTLRule) TTLPolicy {
+	return func(key string) time.Duration {
+		for _, rule := range rules {
+			if strings.Contains(key, rule.Pattern) {
+				return rule.TTL
+			}
+		}
+		return 0
+	}
+}
+
+type expiryItem struct {
+	key       string
+	expiresAt time.Time
+	index     int //position in the heap, kept up to date by Swap so entries can be removed directly
+}
+
+// expiryHeap is a min heap on expiresAt so the reaper only ever looks at the next entry due
+type expiryHeap []*expiryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	item := x.(*expiryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// SetTTLPolicy sets the policy Add uses to pick per key lifetimes. Entries already cached keep their deadline.
+func (c *Cache) SetTTLPolicy(policy TTLPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttlPolicy = policy
+}
+
+// ttlFor must be called with the lock held
+func (c *Cache) ttlFor(key string) time.Duration {
+	if c.ttlPolicy != nil {
+		if ttl := c.ttlPolicy(key); ttl > 0 {
+			return ttl
+		}
+	}
+	return c.intervalTimer
+}
+
+// reapExpired drops every entry past its deadline, callers must hold the write lock
+func (c *Cache) reapExpired(now time.Time) {
+	for c.expiry.Len() > 0 && !c.expiry[0].expiresAt.After(now) {
+		c.removeEntry(c.expiry[0].key)
+		c.stats.Expirations++
+	}
+}
+
+// nextWait is how long the reaper can sleep before the next entry is due
+func (c *Cache) nextWait(now time.Time) time.Duration {
+	if c.expiry.Len() == 0 {
+		return c.intervalTimer //nothing to reap, Add wakes us if something shows up sooner
+	}
+	return c.expiry[0].expiresAt.Sub(now)
+}
+
+func (c *Cache) wakeReaper() {
+	select {
+	case c.wake <- struct{}{}:
+	default: //a wake up is already pending
+	}
+}
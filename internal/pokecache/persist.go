@@ -0,0 +1,113 @@
+package pokecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/atomicfile"
+	"os"
+	"time"
+)
+
+// snapshotVersion is bumped whenever the on disk layout or what the values hold changes. Version 1 had no expires_at,
+// version 2 held values the client had decoded and re-encoded, so they lack any field added to its types since.
+const snapshotVersion = 3
+
+const rawSinceVersion = 3 //first version whose values are the raw response bodies
+
+var (
+	ErrCorruptSnapshot     = errors.New("cache snapshot is corrupt")
+	ErrUnsupportedSnapshot = errors.New("cache snapshot version is not supported")
+)
+
+type snapshotFile struct {
+	Version  int             `json:"version"`
+	Checksum string          `json:"checksum"` //sha256 of the raw entries json, catches truncated or edited files
+	Entries  json.RawMessage `json:"entries"`
+}
+
+type snapshotEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Val       []byte    `json:"val"`
+}
+
+// Save writes every live entry to path atomically, so a crash mid save leaves the old snapshot intact.
+func (c *Cache) Save(path string) error {
+	c.mu.RLock()
+	entries := make(map[string]snapshotEntry, len(c.cache))
+	for key, entry := range c.cache {
+		entries[key] = snapshotEntry{
+			CreatedAt: entry.createdAt,
+			ExpiresAt: entry.expiry.expiresAt,
+			Val:       entry.val,
+		}
+	}
+	c.mu.RUnlock()
+
+	rawEntries, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshotFile{
+		Version:  snapshotVersion,
+		Checksum: checksum(rawEntries),
+		Entries:  rawEntries,
+	})
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.Write(path, data)
+}
+
+// Load reads a snapshot written by Save into the cache. A missing file is not an error, it just means there is nothing to restore yet.
+// Entries past their deadline are dropped instead of being loaded, and so is everything in a snapshot older than rawSinceVersion.
+func (c *Cache) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot snapshotFile
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > snapshotVersion {
+		return fmt.Errorf("%w: got version %d, want %d", ErrUnsupportedSnapshot, snapshot.Version, snapshotVersion)
+	}
+	if checksum(snapshot.Entries) != snapshot.Checksum {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorruptSnapshot)
+	}
+
+	var entries map[string]snapshotEntry
+	if err := json.Unmarshal(snapshot.Entries, &entries); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+	}
+	if snapshot.Version < rawSinceVersion {
+		return nil //refetching is cheaper than decoding into types that have grown fields since
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for key, entry := range entries {
+		if !entry.ExpiresAt.After(now) {
+			continue //expired while we were closed
+		}
+		c.addEntry(key, entry.CreatedAt, entry.ExpiresAt, entry.Val)
+	}
+	c.evictOverLimit()
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
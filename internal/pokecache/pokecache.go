@@ -1,6 +1,9 @@
 package pokecache
 
 import (
+	"container/heap"
+	"container/list"
+	"context"
 	"errors"
 	"sync"
 	"time"
@@ -10,14 +13,31 @@ type Cache struct {
 	cache         map[string]cacheEntry
 	intervalTimer time.Duration //time cache is alloweed to live
 	mu            *sync.RWMutex //RWMutex for concurrent access
+	lru           *list.List    //keys ordered from most to least recently used
+	totalBytes    int
+	maxEntries    int           //0 means no limit
+	maxBytes      int           //0 means no limit
+	done          chan struct{} //closed by Close to stop the reaper
+	closeOnce     *sync.Once
+	expiry        expiryHeap    //entries ordered by deadline
+	ttlPolicy     TTLPolicy     //nil means every entry lives for intervalTimer
+	wake          chan struct{} //tells the reaper an earlier deadline was added
+	stats         Stats         //only the counters are kept here, sizes are filled in by Stats()
 }
 
 type cacheEntry struct {
 	createdAt time.Time
-	val       []byte //can be any data that can be marshaled into bytes
+	val       []byte        //can be any data that can be marshaled into bytes
+	element   *list.Element //position of this key in the lru list
+	expiry    *expiryItem
 }
 
 func NewCache(durationType string, durationLife int) (*Cache, error) {
+	return NewCacheWithContext(context.Background(), durationType, durationLife)
+}
+
+// NewCacheWithContext works like NewCache, but the reaper also stops once ctx is cancelled.
+func NewCacheWithContext(ctx context.Context, durationType string, durationLife int) (*Cache, error) {
 	invalidDurationType := errors.New("invalid duration type provided")
 	valueIsNil := errors.New("One or more values provided are nil")
 
@@ -40,59 +60,139 @@ func NewCache(durationType string, durationLife int) (*Cache, error) {
 		cache:         make(map[string]cacheEntry),
 		intervalTimer: time.Duration(durationLife) * timeVersion,
 		mu:            &sync.RWMutex{},
+		lru:           list.New(),
+		done:          make(chan struct{}),
+		closeOnce:     &sync.Once{},
+		wake:          make(chan struct{}, 1),
 	}
 
-	go c.reapLoop() //start the reaping loop immediately after cache creation to begin cuncurrent reaping
+	go c.reapLoop(ctx) //start the reaping loop immediately after cache creation to begin cuncurrent reaping
 
 	return c, nil
 
 }
 
+// SetLimits caps the cache by entry count and total value size, evicting the least recently used entries once either is exceeded. A limit of 0 disables it.
+func (c *Cache) SetLimits(maxEntries, maxBytes int) error {
+	if maxEntries < 0 || maxBytes < 0 {
+		return errors.New("cache limits cannot be negative")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	c.evictOverLimit()
+	return nil
+}
+
+// Add stores val with a lifetime picked by the TTL policy, or the cache lifetime when no policy matches
 func (c *Cache) Add(key string, val []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.cache[key] = cacheEntry{
-		createdAt: time.Now(),
-		val:       val,
+	now := time.Now()
+	c.addEntry(key, now, now.Add(c.ttlFor(key)), val)
+	c.evictOverLimit()
+}
+
+// AddWithTTL stores val for exactly ttl, ignoring the policy
+func (c *Cache) AddWithTTL(key string, val []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return //already expired, nothing worth storing
 	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.addEntry(key, now, now.Add(ttl), val)
+	c.evictOverLimit()
 }
 
 func (c *Cache) Get(key string) ([]byte, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock() //full lock since a hit reorders the lru list
+	defer c.mu.Unlock()
 
 	entry, exists := c.cache[key]
 	if !exists {
+		c.stats.Misses++
 		return nil, false
 	}
+	c.stats.Hits++
+	c.lru.MoveToFront(entry.element)
 	return entry.val, true
 }
 
-func (c *Cache) reapLoop() {
-	ticker := time.NewTicker(c.intervalTimer)
-	defer ticker.Stop()
-	for range ticker.C {
-		c.mu.RLock()
-		if len(c.cache) == 0 {
-			c.mu.RUnlock()
-			continue
-		}
+// addEntry and removeEntry keep the map, lru list and byte count in sync, callers must hold the write lock
+func (c *Cache) addEntry(key string, createdAt, expiresAt time.Time, val []byte) {
+	if _, exists := c.cache[key]; exists {
+		c.removeEntry(key)
+	}
+	item := &expiryItem{key: key, expiresAt: expiresAt}
+	heap.Push(&c.expiry, item)
+	c.cache[key] = cacheEntry{
+		createdAt: createdAt,
+		val:       val,
+		element:   c.lru.PushFront(key),
+		expiry:    item,
+	}
+	c.totalBytes += len(val)
+	if item.index == 0 {
+		c.wakeReaper() //new earliest deadline, the reaper may be sleeping past it
+	}
+}
 
-		var keyDeletion []string
-		for key, entry := range c.cache {
-			if time.Since(entry.createdAt) > c.intervalTimer {
-				keyDeletion = append(keyDeletion, key)
-			}
+func (c *Cache) removeEntry(key string) {
+	entry, exists := c.cache[key]
+	if !exists {
+		return
+	}
+	c.lru.Remove(entry.element)
+	heap.Remove(&c.expiry, entry.expiry.index)
+	c.totalBytes -= len(entry.val)
+	delete(c.cache, key)
+}
+
+func (c *Cache) evictOverLimit() {
+	for c.lru.Len() > 0 {
+		overEntries := c.maxEntries > 0 && len(c.cache) > c.maxEntries
+		overBytes := c.maxBytes > 0 && c.totalBytes > c.maxBytes
+		if !overEntries && !overBytes {
+			return
 		}
-		c.mu.RUnlock()
-
-		if len(keyDeletion) > 0 {
-			c.mu.Lock()
-			for _, key := range keyDeletion {
-				delete(c.cache, key)
-			}
-			c.mu.Unlock()
+		oldest := c.lru.Back()
+		c.removeEntry(oldest.Value.(string))
+		c.stats.Evictions++
+	}
+}
+
+// Close stops the reaper goroutine. Entries stay readable afterwards, they just no longer expire, so it is safe to Save after closing.
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *Cache) reapLoop(ctx context.Context) {
+	timer := time.NewTimer(c.intervalTimer)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		c.reapExpired(now)
+		wait := c.nextWait(now)
+		c.mu.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-c.done:
+			return
+		case <-ctx.Done():
+			return
+		case <-c.wake:
+		case <-timer.C:
 		}
 	}
 }
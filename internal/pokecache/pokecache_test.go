@@ -1,7 +1,13 @@
 package pokecache
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -29,6 +35,7 @@ func TestAddGet(t *testing.T) {
 				t.Errorf("unexpected error creating cache: %v", err)
 				return
 			}
+			defer cache.Close()
 			cache.Add(c.key, c.val)
 			val, ok := cache.Get(c.key)
 			if !ok {
@@ -51,6 +58,7 @@ func TestReapLoop(t *testing.T) {
 		t.Errorf("unexpected error creating cache: %v", err)
 		return
 	}
+	defer cache.Close()
 	cache.Add("https://example.com", []byte("testdata"))
 
 	_, ok := cache.Get("https://example.com")
@@ -67,3 +75,324 @@ func TestReapLoop(t *testing.T) {
 		return
 	}
 }
+
+func TestPerEntryTTL(t *testing.T) {
+	cache, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer cache.Close()
+	cache.SetTTLPolicy(NewPatternPolicy(
+		TTLRule{Pattern: "/location-area?offset=", TTL: time.Second},
+		TTLRule{Pattern: "/pokemon/", TTL: 30 * 24 * time.Hour},
+	))
+
+	cache.Add("https://pokeapi.co/api/v2/location-area?offset=20", []byte("page"))
+	cache.Add("https://pokeapi.co/api/v2/pokemon/pikachu", []byte("pikachu"))
+	cache.Add("https://pokeapi.co/api/v2/location-area/canalave-city-area", []byte("area"))
+	cache.AddWithTTL("https://example.com", []byte("testdata"), time.Second)
+
+	time.Sleep(2 * time.Second) //well under the 15 minute default, so only the short ttls can have been reaped
+
+	cases := []struct {
+		key    string
+		exists bool
+	}{
+		{key: "https://pokeapi.co/api/v2/location-area?offset=20", exists: false},
+		{key: "https://example.com", exists: false},
+		{key: "https://pokeapi.co/api/v2/pokemon/pikachu", exists: true},
+		{key: "https://pokeapi.co/api/v2/location-area/canalave-city-area", exists: true},
+	}
+	for _, c := range cases {
+		if _, ok := cache.Get(c.key); ok != c.exists {
+			t.Errorf("expected %q present=%v, got %v", c.key, c.exists, ok)
+		}
+	}
+
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	if cache.expiry.Len() != len(cache.cache) {
+		t.Errorf("expiry heap out of sync, got %d items for %d entries", cache.expiry.Len(), len(cache.cache))
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	cases := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int
+		touch      string //key read before the final add, so it should survive
+		evicted    []string
+		kept       []string
+	}{
+		{
+			name:       "entry limit",
+			maxEntries: 2,
+			evicted:    []string{"a"},
+			kept:       []string{"b", "c"},
+		},
+		{
+			name:       "entry limit with hit",
+			maxEntries: 2,
+			touch:      "a",
+			evicted:    []string{"b"},
+			kept:       []string{"a", "c"},
+		},
+		{
+			name:     "byte limit",
+			maxBytes: 8,
+			evicted:  []string{"a"},
+			kept:     []string{"b", "c"},
+		},
+		{
+			name:     "byte limit with hit",
+			maxBytes: 8,
+			touch:    "a",
+			evicted:  []string{"b"},
+			kept:     []string{"a", "c"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cache, err := NewCache("minute", 15)
+			if err != nil {
+				t.Errorf("unexpected error creating cache: %v", err)
+				return
+			}
+			defer cache.Close()
+			if err := cache.SetLimits(c.maxEntries, c.maxBytes); err != nil {
+				t.Errorf("unexpected error setting limits: %v", err)
+				return
+			}
+			cache.Add("a", []byte("1234"))
+			cache.Add("b", []byte("1234"))
+			if c.touch != "" {
+				cache.Get(c.touch)
+			}
+			cache.Add("c", []byte("1234"))
+
+			for _, key := range c.evicted {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("expected %q to be evicted", key)
+				}
+			}
+			for _, key := range c.kept {
+				if _, ok := cache.Get(key); !ok {
+					t.Errorf("expected %q to be kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUConcurrentAccess(t *testing.T) {
+	cache, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer cache.Close()
+	if err := cache.SetLimits(10, 0); err != nil {
+		t.Errorf("unexpected error setting limits: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("key-%d", (worker*j)%25)
+				cache.Add(key, []byte("testdata"))
+				cache.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	if len(cache.cache) > 10 || cache.lru.Len() != len(cache.cache) {
+		t.Errorf("expected at most 10 entries with a matching lru list, got %d entries and %d list items", len(cache.cache), cache.lru.Len())
+	}
+	if cache.totalBytes != len(cache.cache)*len("testdata") {
+		t.Errorf("byte count out of sync, got %d", cache.totalBytes)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cache, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer cache.Close()
+	cache.Add("https://example.com", []byte("testdata"))
+	cache.mu.Lock()
+	cache.addEntry("https://example.com/old", time.Now().Add(-time.Hour), time.Now().Add(-time.Minute), []byte("stale"))
+	cache.mu.Unlock()
+
+	if err := cache.Save(path); err != nil {
+		t.Errorf("unexpected error saving cache: %v", err)
+		return
+	}
+
+	loaded, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer loaded.Close()
+	if err := loaded.Load(path); err != nil {
+		t.Errorf("unexpected error loading cache: %v", err)
+		return
+	}
+	val, ok := loaded.Get("https://example.com")
+	if !ok || string(val) != "testdata" {
+		t.Errorf("expected to find saved value")
+		return
+	}
+	if _, ok := loaded.Get("https://example.com/old"); ok {
+		t.Errorf("expected expired entry to be dropped on load")
+	}
+}
+
+func TestLoadMissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer cache.Close()
+	if err := cache.Load(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("expected missing snapshot to be ignored, got %v", err)
+	}
+
+	path := filepath.Join(dir, "cache.json")
+	cache.Add("https://example.com", []byte("testdata"))
+	if err := cache.Save(path); err != nil {
+		t.Errorf("unexpected error saving cache: %v", err)
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("unexpected error reading snapshot: %v", err)
+		return
+	}
+	tampered := strings.Replace(string(data), `"val":"`, `"val":"AA`, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0o644); err != nil {
+		t.Errorf("unexpected error writing snapshot: %v", err)
+		return
+	}
+	if err := cache.Load(path); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("expected ErrCorruptSnapshot, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version":`), 0o644); err != nil {
+		t.Errorf("unexpected error writing snapshot: %v", err)
+		return
+	}
+	if err := cache.Load(path); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("expected ErrCorruptSnapshot for truncated file, got %v", err)
+	}
+}
+
+func TestLoadDropsDecodedSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	entries := fmt.Sprintf(`{"https://example.com/pokemon-species/tentacool":{"created_at":%q,"expires_at":%q,"val":"eyJuYW1lIjoidGVudGFjb29sIn0="}}`,
+		time.Now().Format(time.RFC3339Nano), time.Now().Add(time.Hour).Format(time.RFC3339Nano))
+	old := fmt.Sprintf(`{"version":2,"checksum":%q,"entries":%s}`, checksum([]byte(entries)), entries)
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Errorf("unexpected error writing snapshot: %v", err)
+		return
+	}
+
+	cache, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer cache.Close()
+	if err := cache.Load(path); err != nil {
+		t.Errorf("expected an old snapshot to load without error, got %v", err)
+	}
+	if _, ok := cache.Get("https://example.com/pokemon-species/tentacool"); ok {
+		t.Errorf("expected entries from a version 2 snapshot to be dropped, they were decoded with older types")
+	}
+}
+
+func TestCloseStopsReaper(t *testing.T) {
+	cases := []struct {
+		name string
+		stop func(cache *Cache, cancel context.CancelFunc)
+	}{
+		{
+			name: "close",
+			stop: func(cache *Cache, cancel context.CancelFunc) { cache.Close() },
+		},
+		{
+			name: "context cancel",
+			stop: func(cache *Cache, cancel context.CancelFunc) { cancel() },
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			cache, err := NewCacheWithContext(ctx, "second", 1)
+			if err != nil {
+				t.Errorf("unexpected error creating cache: %v", err)
+				return
+			}
+			cache.Add("https://example.com", []byte("testdata"))
+			c.stop(cache, cancel)
+			cache.Close() //closing twice must not panic
+
+			time.Sleep(3 * time.Second)
+
+			if _, ok := cache.Get("https://example.com"); !ok {
+				t.Errorf("expected stopped reaper to leave entries alone")
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	cache, err := NewCache("minute", 15)
+	if err != nil {
+		t.Errorf("unexpected error creating cache: %v", err)
+		return
+	}
+	defer cache.Close()
+	if err := cache.SetLimits(2, 0); err != nil {
+		t.Errorf("unexpected error setting limits: %v", err)
+		return
+	}
+
+	cache.Add("a", []byte("1234"))
+	cache.Add("b", []byte("12"))
+	cache.Get("a")
+	cache.Get("missing")
+	cache.Add("c", []byte("1")) //pushes out b, the least recently used
+	cache.Peek("a")             //peeking must not count as a hit
+
+	stats := cache.Stats()
+	expected := Stats{Hits: 1, Misses: 1, Evictions: 1, Entries: 2, Bytes: 5}
+	if stats != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, stats)
+	}
+
+	if !cache.Delete("a") || cache.Delete("a") {
+		t.Errorf("expected Delete to report removal exactly once")
+	}
+	cache.Clear()
+	if entries := cache.Entries(); len(entries) != 0 {
+		t.Errorf("expected no entries after Clear, got %d", len(entries))
+	}
+}
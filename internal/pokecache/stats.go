@@ -0,0 +1,91 @@
+package pokecache
+
+import (
+	"sort"
+	"time"
+)
+
+type Stats struct {
+	Hits        int
+	Misses      int
+	Evictions   int //dropped to stay under the size limits
+	Expirations int //dropped by the reaper once their ttl ran out
+	Entries     int
+	Bytes       int
+}
+
+type EntryInfo struct {
+	Key       string
+	Size      int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := c.stats
+	stats.Entries = len(c.cache)
+	stats.Bytes = c.totalBytes
+	return stats
+}
+
+// Entries lists every cached key sorted by key, without touching the lru order or hit counters
+func (c *Cache) Entries() []EntryInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	infos := make([]EntryInfo, 0, len(c.cache))
+	for key, entry := range c.cache {
+		infos = append(infos, entry.info(key))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+	return infos
+}
+
+// Peek is Get for debugging, it does not count as a hit or mark the entry as recently used
+func (c *Cache) Peek(key string) ([]byte, EntryInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.cache[key]
+	if !exists {
+		return nil, EntryInfo{}, false
+	}
+	return entry.val, entry.info(key), true
+}
+
+// Delete removes a single entry and reports whether it was there
+func (c *Cache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.cache[key]; !exists {
+		return false
+	}
+	c.removeEntry(key)
+	return true
+}
+
+// Clear drops every entry, counters are kept so stats still make sense afterwards
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.cache {
+		c.removeEntry(key)
+	}
+}
+
+func (e cacheEntry) info(key string) EntryInfo {
+	return EntryInfo{
+		Key:       key,
+		Size:      len(e.val),
+		CreatedAt: e.createdAt,
+		ExpiresAt: e.expiry.expiresAt,
+	}
+}
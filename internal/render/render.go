@@ -0,0 +1,108 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type Format string
+
+const (
+	Text  Format = "text" //what the pokedex has always printed, meant for people
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	Table Format = "table"
+)
+
+var Formats = []Format{Text, JSON, YAML, Table}
+
+// Result is what a command hands back instead of printing. The exported fields and their json tags are the
+// structured form, so renaming one breaks anyone piping json output into other tools.
+type Result interface {
+	WriteText(w io.Writer) error
+}
+
+// Tabler is implemented by results that have a natural table form, the rest fall back to text for the table format
+type Tabler interface {
+	Table() (header []string, rows [][]string)
+}
+
+func ParseFormat(raw string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(raw)))
+	for _, known := range Formats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("invalid output format %q, expected text, json, yaml or table", raw)
+}
+
+// Write renders result to w in format, an empty format is treated as text
+func Write(w io.Writer, format Format, result Result) error {
+	switch format {
+	case JSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case YAML:
+		return writeYAML(w, result)
+	case Table:
+		tabler, ok := result.(Tabler)
+		if !ok {
+			return result.WriteText(w)
+		}
+		header, rows := tabler.Table()
+		return writeTable(w, header, rows)
+	default:
+		return result.WriteText(w)
+	}
+}
+
+// writeYAML goes through the json form so keys and their order always match the json output, without a second set of tags
+func writeYAML(w io.Writer, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node) //json parses as flow style, which reads like json again
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		node.Style &^= yaml.DoubleQuotedStyle //only keep quotes where yaml needs them, the encoder adds those back
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	upper := make([]string, len(header))
+	for i, column := range header {
+		upper[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(tw, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type trainer struct {
+	Name    string   `json:"name"`
+	Badges  int      `json:"badges"`
+	Pokemon []string `json:"pokemon"`
+}
+
+func (t trainer) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s has %d badges\n", t.Name, t.Badges)
+	return err
+}
+
+type trainerTable struct {
+	trainer
+}
+
+func (t trainerTable) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, pokemon := range t.Pokemon {
+		rows = append(rows, []string{pokemon, t.Name})
+	}
+	return []string{"pokemon", "trainer"}, rows
+}
+
+func TestWrite(t *testing.T) {
+	ash := trainer{Name: "ash", Badges: 8, Pokemon: []string{"pikachu", "bulbasaur"}}
+
+	cases := []struct {
+		name   string
+		format Format
+		result Result
+		want   string
+	}{
+		{name: "text", format: Text, result: ash, want: "ash has 8 badges\n"},
+		{name: "empty format is text", format: "", result: ash, want: "ash has 8 badges\n"},
+		{
+			name:   "json",
+			format: JSON,
+			result: ash,
+			want:   "{\n  \"name\": \"ash\",\n  \"badges\": 8,\n  \"pokemon\": [\n    \"pikachu\",\n    \"bulbasaur\"\n  ]\n}\n",
+		},
+		{
+			name:   "yaml keeps json keys and order",
+			format: YAML,
+			result: ash,
+			want:   "name: ash\nbadges: 8\npokemon:\n  - pikachu\n  - bulbasaur\n",
+		},
+		{
+			name:   "table",
+			format: Table,
+			result: trainerTable{ash},
+			want:   "POKEMON    TRAINER\npikachu    ash\nbulbasaur  ash\n",
+		},
+		{name: "table falls back to text", format: Table, result: ash, want: "ash has 8 badges\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Write(&out, c.format, c.result); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != c.want {
+				t.Errorf("expected:\n%q\nreceived:\n%q", c.want, out.String())
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, raw := range []string{"json", " YAML ", "Table", "text"} {
+		if _, err := ParseFormat(raw); err != nil {
+			t.Errorf("unexpected error parsing %q: %v", raw, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected xml to be rejected")
+	}
+}
@@ -0,0 +1,152 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"io"
+	"strconv"
+	"time"
+)
+
+const cacheUsage = "Usage: cache <stats|list|show <key>|evict <key>|clear>"
+
+func commandCache(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	switch args[0] {
+	case "stats":
+		return cacheStats(cfg), nil
+	case "list":
+		return cacheList(cfg), nil
+	case "show":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("Please provide a key to show. Usage: cache show <key>")
+		}
+		return cacheShow(cfg, args[1])
+	case "evict":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("Please provide a key to evict. Usage: cache evict <key>")
+		}
+		if !cfg.cache.Delete(args[1]) {
+			return nil, fmt.Errorf("No cache entry for %s", args[1])
+		}
+		return message("Evicted %s", args[1]), nil
+	case "clear":
+		cfg.cache.Clear()
+		return message("Cache cleared."), nil
+	default:
+		return nil, fmt.Errorf("Unknown cache subcommand: %s\n%s", args[0], cacheUsage)
+	}
+}
+
+type cacheStatsResult struct {
+	Entries     int     `json:"entries"`
+	Bytes       int     `json:"bytes"`
+	Hits        int     `json:"hits"`
+	Misses      int     `json:"misses"`
+	HitRate     float64 `json:"hit_rate"` //percent of lookups that were hits
+	Evictions   int     `json:"evictions"`
+	Expirations int     `json:"expirations"`
+}
+
+func cacheStats(cfg *config) cacheStatsResult {
+	stats := cfg.cache.Stats()
+	lookups := stats.Hits + stats.Misses
+	hitRate := 0.0
+	if lookups > 0 {
+		hitRate = float64(stats.Hits) / float64(lookups) * 100
+	}
+	return cacheStatsResult{
+		Entries:     stats.Entries,
+		Bytes:       stats.Bytes,
+		Hits:        stats.Hits,
+		Misses:      stats.Misses,
+		HitRate:     hitRate,
+		Evictions:   stats.Evictions,
+		Expirations: stats.Expirations,
+	}
+}
+
+func (r cacheStatsResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Entries:     %d\n", r.Entries)
+	fmt.Fprintf(w, "Bytes:       %d\n", r.Bytes)
+	fmt.Fprintf(w, "Hits:        %d\n", r.Hits)
+	fmt.Fprintf(w, "Misses:      %d\n", r.Misses)
+	fmt.Fprintf(w, "Hit rate:    %.1f%%\n", r.HitRate)
+	fmt.Fprintf(w, "Evictions:   %d\n", r.Evictions)
+	_, err := fmt.Fprintf(w, "Expirations: %d\n", r.Expirations)
+	return err
+}
+
+type cacheEntryInfo struct {
+	Key       string    `json:"key"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type cacheListResult struct {
+	Entries []cacheEntryInfo `json:"entries"`
+}
+
+func cacheList(cfg *config) cacheListResult {
+	result := cacheListResult{Entries: []cacheEntryInfo{}}
+	for _, entry := range cfg.cache.Entries() {
+		result.Entries = append(result.Entries, cacheEntryInfo{Key: entry.Key, Size: entry.Size, CreatedAt: entry.CreatedAt, ExpiresAt: entry.ExpiresAt})
+	}
+	return result
+}
+
+func (r cacheListResult) WriteText(w io.Writer) error {
+	if len(r.Entries) == 0 {
+		_, err := fmt.Fprintln(w, "The cache is empty.")
+		return err
+	}
+	for _, entry := range r.Entries {
+		fmt.Fprintf(w, " - %s (%d bytes, expires in %s)\n", entry.Key, entry.Size, time.Until(entry.ExpiresAt).Round(time.Second))
+	}
+	return nil
+}
+
+func (r cacheListResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		rows = append(rows, []string{entry.Key, strconv.Itoa(entry.Size), time.Until(entry.ExpiresAt).Round(time.Second).String()})
+	}
+	return []string{"key", "bytes", "expires in"}, rows
+}
+
+type cacheShowResult struct {
+	cacheEntryInfo
+	Value any `json:"value"` //decoded json, or the raw text for anything that isn't json
+	raw   []byte
+}
+
+func cacheShow(cfg *config, key string) (render.Result, error) {
+	val, info, exists := cfg.cache.Peek(key)
+	if !exists {
+		return nil, fmt.Errorf("No cache entry for %s", key)
+	}
+
+	result := cacheShowResult{cacheEntryInfo: cacheEntryInfo{Key: info.Key, Size: info.Size, CreatedAt: info.CreatedAt, ExpiresAt: info.ExpiresAt}, raw: val}
+	if err := json.Unmarshal(val, &result.Value); err != nil {
+		result.Value = string(val) //not json, show it as is
+	}
+	return result, nil
+}
+
+func (r cacheShowResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Key:     %s\n", r.Key)
+	fmt.Fprintf(w, "Size:    %d bytes\n", r.Size)
+	fmt.Fprintf(w, "Created: %s\n", r.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Expires: %s\n", r.ExpiresAt.Format(time.RFC3339))
+
+	var pretty bytes.Buffer //indent the raw bytes rather than Value so keys keep the order PokeAPI sent them in
+	if err := json.Indent(&pretty, r.raw, "", "  "); err != nil {
+		_, err := fmt.Fprintf(w, "%s\n", r.raw) //not json, print it as is
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s\n", pretty.String())
+	return err
+}
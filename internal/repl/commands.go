@@ -1,230 +1,389 @@
 package repl
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
 	"github.com/CSelvidge/pokedexcli/internal/actors"
-	"os"
+	"github.com/CSelvidge/pokedexcli/internal/capture"
+	"github.com/CSelvidge/pokedexcli/internal/encounter"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"slices"
+	"strconv"
 	"strings"
-	"math/rand"
-	"time"
-	"encoding/json"
 )
 
-func commandExit(cfg *config, args ...string) error {
-	fmt.Println("Closing the Pokedex... Goodbye!")
-	os.Exit(0)
-	return nil
+// errExit is how exit asks to leave, the REPL, scripts and one shot mode each decide what leaving means for them
+var errExit = errors.New("exit requested")
+
+var goodbye = message("Closing the Pokedex... Goodbye!")
+
+func commandExit(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	return nil, errExit
 }
 
-func commandHelp(cfg *config, args ...string) error {
-	fmt.Println("Available commands:")
-	for _, cmd := range commandDictionary {
-		fmt.Printf(" - %s: %s\n", cmd.name, cmd.description)
+// describeAPIError swaps typed pokeapi errors for messages a trainer can act on, notFound is used for 404s since only the caller knows what was missing
+func describeAPIError(err error, notFound string) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("Command cancelled.")
+	case errors.Is(err, pokeapi.ErrNotFound):
+		return errors.New(notFound)
+	case errors.Is(err, pokeapi.ErrNotInSnapshot):
+		return fmt.Errorf("That is not in the offline snapshot, use the snapshot command while online to download it.")
+	case errors.Is(err, pokeapi.ErrRateLimited):
+		return fmt.Errorf("PokeAPI is rate limiting requests, please wait a moment and try again.")
+	case errors.Is(err, pokeapi.ErrServer):
+		return fmt.Errorf("PokeAPI is having trouble right now, please try again later.")
+	case errors.Is(err, pokeapi.ErrNetwork):
+		return fmt.Errorf("Could not reach PokeAPI, check your connection and try again: %w", err)
+	case errors.Is(err, pokeapi.ErrDecode):
+		return fmt.Errorf("PokeAPI sent a response the Pokedex could not read: %w", err)
 	}
-	return nil
+	return err
 }
 
-func commandMap(cfg *config, args ...string) error {
-	var locationMap locationResponse
-
-	url := ""
-	if cfg.nextLocationsURL == "" {
-		url = "https://pokeapi.co/api/v2/location-area"
-	} else {
-		url = cfg.nextLocationsURL
+func saveCache(cfg *config) error {
+	if cfg.cacheFile == "" {
+		return nil
 	}
+	return cfg.cache.Save(cfg.cacheFile)
+}
 
-	if err := pokeapi.GenericURLCaller(url, cfg.cache, &locationMap); err != nil {
-		fmt.Printf("Error fetching locations: %v\n", err)
-		return err
+func commandHelp(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if len(args) > 0 {
+		cmd, exists := commandDictionary[args[0]]
+		if !exists {
+			return nil, fmt.Errorf("Unknown command: %s", args[0])
+		}
+		return describeCommand(cmd), nil
 	}
 
-	cfg.nextLocationsURL = locationMap.Next
-	cfg.previousLocationsURL = locationMap.Previous
-
-	for _, location := range locationMap.Results {
-		fmt.Printf("%s\n", location.Name)
+	help := helpResult{}
+	for _, name := range commandNames() { //sorted, map order is random and help should be stable
+		help.Commands = append(help.Commands, describeCommand(commandDictionary[name]))
 	}
+	return help, nil
+}
 
-	return nil
+func describeCommand(cmd cliCommand) commandInfo {
+	info := commandInfo{Name: cmd.name, Usage: cmd.usage(), Description: cmd.description}
+	for _, spec := range cmd.flags {
+		info.Flags = append(info.Flags, flagInfo{Name: spec.name, Short: spec.short, Usage: spec.usage})
+	}
+	return info
 }
 
-func commandMapb(cfg *config, args ...string) error {
-	var locationMap locationResponse
+func commandMap(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	return showLocationPage(ctx, cfg, cfg.nextLocationPage)
+}
 
-	url := ""
-	if cfg.previousLocationsURL == "" {
-		fmt.Println("No previous locations available. You must advance at least once first.")
-		return nil
-	} else {
-		url = cfg.previousLocationsURL
+func commandMapb(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if cfg.previousLocationPage < 0 {
+		return message("No previous locations available. You must advance at least once first."), nil
 	}
+	return showLocationPage(ctx, cfg, cfg.previousLocationPage)
+}
 
-	if err := pokeapi.GenericURLCaller(url, cfg.cache, &locationMap); err != nil {
-		fmt.Printf("Error fetching locations: %v\n", err)
-		return err
+func showLocationPage(ctx context.Context, cfg *config, page int) (render.Result, error) {
+	if cfg.version != nil {
+		return showVersionLocationPage(ctx, cfg, page)
+	}
+	locationMap, err := cfg.client.ListLocationAreas(ctx, page)
+	if err != nil {
+		return nil, describeAPIError(err, "Error fetching locations: that page of locations does not exist")
 	}
 
-	cfg.previousLocationsURL = locationMap.Previous
-	cfg.nextLocationsURL = locationMap.Next
-
+	areas := []string{}
 	for _, location := range locationMap.Results {
-		fmt.Printf("%s\n", location.Name)
+		areas = append(areas, location.Name)
 	}
-
-	return nil
+	return setLocationPage(cfg, page, areas, locationMap.Next != "", locationMap.Previous != ""), nil
 }
 
-func commandExplore(cfg *config, args ...string) error {
-	var locationInfo exploreResponse
-	foundPokemon := []string{}
-	if len(args) == 0 {
-		fmt.Println("Please provide a location name to explore. Usage: explore <location-name>")
-		return nil
+// showVersionLocationPage pages through the locations in the version's regions instead of every area PokeAPI has,
+// listing the areas of each. A page can hold more or fewer than LocationPageSize areas since locations have several or none.
+func showVersionLocationPage(ctx context.Context, cfg *config, page int) (render.Result, error) {
+	if cfg.versionLocations == nil {
+		locations := []string{}
+		for _, regionName := range cfg.version.Regions {
+			region, err := cfg.client.GetRegion(ctx, regionName)
+			if err != nil {
+				return nil, describeAPIError(err, fmt.Sprintf("PokeAPI has no region named %s.", regionName))
+			}
+			for _, location := range region.Locations {
+				locations = append(locations, location.Name)
+			}
+		}
+		cfg.versionLocations = locations
 	}
-	locationName := args[0]
-	url := "https://pokeapi.co/api/v2/location-area/" + locationName
 
-	if err := pokeapi.GenericURLCaller(url, cfg.cache, &locationInfo); err != nil {
-		fmt.Printf("Error exploring location: %v\n", err)
-		return err
+	start := page * pokeapi.LocationPageSize
+	if start > 0 && start >= len(cfg.versionLocations) {
+		return nil, fmt.Errorf("Error fetching locations: that page of locations does not exist")
 	}
-	cfg.currentLocation = locationName
-	cfg.currentLocationURL = url
-	fmt.Printf("Exploring %s...\n", locationInfo.Name)
-	for _, encounter := range locationInfo.PokemonEncounters {
-		foundPokemon = append(foundPokemon, encounter.Pokemon.Name)
+	end := min(start+pokeapi.LocationPageSize, len(cfg.versionLocations))
+	areas := []string{}
+	for _, name := range cfg.versionLocations[start:end] {
+		location, err := cfg.client.GetLocation(ctx, name)
+		if err != nil {
+			return nil, describeAPIError(err, fmt.Sprintf("PokeAPI has no location named %s.", name))
+		}
+		for _, area := range location.Areas {
+			areas = append(areas, area.Name)
+		}
 	}
-	if len(foundPokemon) == 0 {
-		fmt.Printf("No Pokemon found in %s.\n", locationInfo.Name)
-		return nil
+	return setLocationPage(cfg, page, areas, end < len(cfg.versionLocations), page > 0), nil
+}
+
+// setLocationPage remembers where map and mapb go next and what the page held, for completion
+func setLocationPage(cfg *config, page int, areas []string, hasNext, hasPrevious bool) render.Result {
+	cfg.nextLocationPage = 0 //wrap back to the first page once the last one has been shown
+	if hasNext {
+		cfg.nextLocationPage = page + 1
 	}
-	fmt.Printf("Found Pokemon:\n")
-	for _, pokemon := range foundPokemon {
-		fmt.Printf(" - %s\n", pokemon)
+	cfg.previousLocationPage = -1
+	if hasPrevious {
+		cfg.previousLocationPage = page - 1
 	}
-	return nil
-
+	cfg.lastMapLocations = areas
+	return locationsResult{Page: page, Locations: append([]string{}, areas...)}
 }
 
-func commandCatch(cfg *config, args ...string) error {
-	if len(args) == 0 {
-		fmt.Println("Please provide a Pokemon name to catch. Usage: catch <pokemon-name>")
-		return nil
+func commandExplore(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	locationName := args[0]
+
+	locationInfo, err := cfg.client.GetLocationArea(ctx, locationName)
+	if err != nil {
+		return nil, describeAPIError(err, fmt.Sprintf("There is no location area named %s. Use map to see locations you can explore.", locationName))
+	}
+	version := cfg.versionName()
+	if version != "" && !encounter.InVersion(locationInfo, version) {
+		return nil, fmt.Errorf("%s is not in pokemon %s, use map to see the areas that are.", locationName, version)
+	}
+	if version != "" && locationInfo.Location.Name != "" && len(cfg.version.Regions) > 0 { //towns have no encounters to tell the version by, their region does
+		location, err := cfg.client.GetLocation(ctx, locationInfo.Location.Name)
+		if err != nil {
+			return nil, describeAPIError(err, fmt.Sprintf("PokeAPI has no location named %s.", locationInfo.Location.Name))
+		}
+		if !slices.Contains(cfg.version.Regions, location.Region.Name) {
+			return nil, fmt.Errorf("%s is not in pokemon %s, use map to see the areas that are.", locationName, version)
+		}
 	}
+	cfg.currentLocation = locationName
+	cfg.currentLocationURL = cfg.client.LocationAreaURL(locationName)
+	cfg.wild = nil //whatever was out stays behind in the old area
+	foundPokemon := encounter.Pokemon(locationInfo, version)
+	cfg.areaPokemon = foundPokemon
+	cfg.areaMethods = encounter.Methods(locationInfo, version)
+	return exploreResult{Location: locationInfo.Name, Pokemon: foundPokemon, Methods: cfg.areaMethods}, nil
+}
 
+func commandCatch(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	ball, status, hp, err := catchOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
 	if cfg.currentLocationURL == "" {
-		return fmt.Errorf("You are in the starting area, please advance and explore a location to begin.")
+		return nil, fmt.Errorf("You are in the starting area, please advance and explore a location to begin.")
 	}
 
-	pokemonName := args[0]
-	exists, err := pokemonStreamingCheck(cfg, pokemonName)
-	if !exists {
-		return fmt.Errorf("Pokemon is not in this area")
+	if cfg.wild == nil {
+		return nil, fmt.Errorf("There is no wild pokemon to catch, use encounter to look for one.")
+	}
+	pokemonName := cfg.wild.Pokemon
+	if len(args) > 0 && args[0] != pokemonName {
+		return nil, fmt.Errorf("The wild pokemon here is %s, not %s.", pokemonName, args[0])
 	}
+
+	pokemon, err := cfg.client.GetPokemon(ctx, pokemonName)
 	if err != nil {
-		fmt.Printf("Error checking byte stream for pokemon\n")
-		return err
+		return nil, describeAPIError(err, fmt.Sprintf("There is no Pokemon named %s.", pokemonName))
 	}
 
-	url := "https://pokeapi.co/api/v2/pokemon/" + pokemonName
-	
-	pokemon:= &actors.Pokemon{}
-	if err := pokeapi.GenericURLCaller(url, cfg.cache, pokemon); err != nil {
-		fmt.Printf("Error fetching Pokemon data: %v\n", err)
-		return err
+	speciesName := pokemon.Species.Name
+	if speciesName == "" {
+		speciesName = pokemon.Name
+	}
+	species, err := cfg.client.GetPokemonSpecies(ctx, speciesName)
+	if err != nil {
+		return nil, describeAPIError(err, fmt.Sprintf("PokeAPI has no species entry for %s.", pokemon.Name))
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	catchChance := rand.Intn(300)
-	fmt.Printf("Throwing a Pokeball at %s...\n", pokemon.Name)
-	if catchChance > pokemon.BaseExperience {
-		fmt.Printf("%s escaped!\n", pokemon.Name)
-	} else {
-		fmt.Printf("%s was caught!\n", pokemon.Name)
-		cfg.user.CaughtPokemon[pokemon.Name] = *pokemon
+	target := capture.Target{CaptureRate: species.CaptureRate, MaxHP: 100, HP: hp, Status: status} //no battles yet, so hp is a percentage
+	throw := capture.Throw(cfg.rng, target, ball)
+	result := catchResult{Pokemon: pokemon.Name, Level: cfg.wild.Level, Ball: ball.String(), Shakes: throw.Shakes, Caught: throw.Caught}
+	if throw.Caught {
+		owned := actors.NewOwnedPokemon(cfg.rng, pokemon, actors.CatchDetails{
+			Level:      cfg.wild.Level,
+			HPPercent:  hp,
+			GenderRate: species.GenderRate,
+			GrowthRate: species.GrowthRate.Name,
+			Location:   cfg.currentLocation,
+			CaughtOn:   cfg.now(),
+		})
+		owned = cfg.user.AddPokemon(owned)
+		result.ID, result.Shiny = owned.ID, owned.Shiny
+		cfg.wild = nil
 	}
-	return nil
+	return result, nil
 }
 
-func pokemonStreamingCheck(cfg *config, args ...string) (bool, error) {
-	if len(args) == 0 {
-		return false, fmt.Errorf("No pokemon name provided")
+func commandEncounter(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if cfg.currentLocationURL == "" {
+		return nil, fmt.Errorf("You are in the starting area, please advance and explore a location to begin.")
+	}
+	method := encounter.Walk
+	if len(args) > 0 {
+		method = args[0]
 	}
 
-
-	cacheData, exists := cfg.cache.Get(cfg.currentLocationURL)
-	if !exists {
-		return false, nil
+	area, err := cfg.client.GetLocationArea(ctx, cfg.currentLocation)
+	if err != nil {
+		return nil, describeAPIError(err, fmt.Sprintf("There is no location area named %s.", cfg.currentLocation))
+	}
+	version := cfg.versionName()
+	if raw, exists := cfg.flags["version"]; exists {
+		version = raw
+	}
+	methods := encounter.Methods(area, version)
+	if len(methods) == 0 && version != "" {
+		if others := encounter.Versions(area, method); len(others) > 0 {
+			return nil, fmt.Errorf("No wild pokemon live in %s in pokemon %s, try --version %s.", cfg.currentLocation, version, strings.Join(others, ", "))
+		}
+		return nil, fmt.Errorf("No wild pokemon live in %s in pokemon %s.", cfg.currentLocation, version)
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("No wild pokemon live in %s.", cfg.currentLocation)
+	}
+	if !slices.Contains(methods, method) {
+		return nil, fmt.Errorf("No pokemon can be found by %s in %s, try %s.", method, cfg.currentLocation, strings.Join(methods, ", "))
 	}
 
-	var partialResponse struct {
-		PokemonEncounters []struct {
-			Pokemon struct {
-				Name string `json:"name"`
-			}`json:"pokemon"`
-		}`json:"pokemon_encounters"`
+	versions := encounter.Versions(area, method)
+	if version == "" {
+		version = versions[0] //the game with the most pokemon for this method
 	}
 
-	err := json.Unmarshal(cacheData, &partialResponse)
+	wild, err := encounter.Roll(cfg.rng, encounter.Slots(area, method, version), method, version)
 	if err != nil {
-		return false, fmt.Errorf("Failed to parse cache data: %w", err)
+		return nil, fmt.Errorf("Could not find a wild pokemon: %w", err)
+	}
+	cfg.wild = &wild
+	return encounterResult{Location: cfg.currentLocation, Encounter: wild}, nil
+}
+
+func commandSeed(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if len(args) == 0 {
+		return seedResult{Seed: cfg.seed}, nil
 	}
+	seed, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil || seed == 0 {
+		return nil, fmt.Errorf("The seed must be a whole number above 0, not %s", args[0])
+	}
+	cfg.reseed(seed)
+	return seedResult{Seed: seed, changed: true}, nil
+}
 
-	for _, encounter := range partialResponse.PokemonEncounters {
-		if strings.EqualFold(encounter.Pokemon.Name, args[0]) {
-			return true, nil
+// catchOptions reads --ball, --status and --hp, which default to a pokeball thrown at a healthy pokemon on full HP
+func catchOptions(cfg *config) (capture.Ball, capture.Status, int, error) {
+	ball, status, hp := capture.PokeBall, capture.Healthy, 100
+	var err error
+	if raw, exists := cfg.flags["ball"]; exists {
+		if ball, err = capture.ParseBall(raw); err != nil {
+			return ball, status, hp, err
 		}
-	
 	}
-	return false, nil
+	if raw, exists := cfg.flags["status"]; exists {
+		if status, err = capture.ParseStatus(raw); err != nil {
+			return ball, status, hp, err
+		}
+	}
+	if raw, exists := cfg.flags["hp"]; exists {
+		hp, err = strconv.Atoi(strings.TrimSuffix(raw, "%"))
+		if err != nil || hp < 1 || hp > 100 {
+			return ball, status, hp, fmt.Errorf("HP must be a percentage from 1 to 100, not %s", raw)
+		}
+	}
+	return ball, status, hp, nil
+}
+
+func commandInspect(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	return inspectPokemon(cfg, args[0], cfg.hasFlag("full"))
 }
 
-func commandInspect(cfg *config, args ...string) error {
-	pokemonName := args[0]
+func commandFullInspect(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	return inspectPokemon(cfg, args[0], true)
+}
 
-	pokemon, exists := cfg.user.CaughtPokemon[pokemonName]
-	if !exists {
-		return  fmt.Errorf("You have not caught a %s\n", pokemonName)
+func inspectPokemon(cfg *config, query string, full bool) (render.Result, error) {
+	found := cfg.user.FindPokemon(query)
+	if len(found) == 0 {
+		return nil, fmt.Errorf("You have not caught a %s", query)
+	}
+	if len(found) > 1 {
+		ids := make([]string, 0, len(found))
+		for _, owned := range found {
+			ids = append(ids, fmt.Sprintf("#%d", owned.ID))
+		}
+		return nil, fmt.Errorf("You have caught %d %s, inspect one by number: %s", len(found), query, strings.Join(ids, ", "))
+	}
+	owned := found[0]
+	pokemon := owned.Data
+
+	result := inspectResult{
+		ID:        owned.ID,
+		Name:      owned.Species,
+		Level:     owned.Level,
+		XP:        owned.XP,
+		HP:        owned.HP,
+		MaxHP:     owned.MaxHP(),
+		Nature:    owned.Nature,
+		Gender:    owned.Gender,
+		Shiny:     owned.Shiny,
+		Abilities: []string{},
+		Moves:     []string{},
+		full:      full,
 	}
-
-	fmt.Printf("Name: %s\nAbilities:\n", pokemon.Name)
 	for _, ability := range pokemon.Abilities {
-		fmt.Printf(" - %s", ability.Ability.Name)
+		result.Abilities = append(result.Abilities, ability.Ability.Name)
 	}
-	fmt.Printf("\nMoves:\n")
 	for _, move := range pokemon.Moves {
-		fmt.Printf(" - %s\n", move.Move.Name)
+		learnable := cfg.version == nil
+		for _, detail := range move.VersionGroupDetails {
+			learnable = learnable || detail.VersionGroup.Name == cfg.version.VersionGroup
+		}
+		if learnable {
+			result.Moves = append(result.Moves, move.Move.Name)
+		}
 	}
-	return nil
-}
-
-func commandFullInspect(cfg *config, args ...string) error {
-	if err := commandInspect(cfg, args[0]); err != nil {
-		return err
+	if !full {
+		return result, nil
 	}
 
-	pokemon := cfg.user.CaughtPokemon[args[0]]
-
-	fmt.Printf("\nTypes:\n")
 	for _, kind := range pokemon.Types {
-		fmt.Printf(" - %s", kind.Type.Name)
+		result.Types = append(result.Types, kind.Type.Name)
 	}
-	fmt.Printf("\nStats:\n")
 	for _, stat := range pokemon.Stats {
-		fmt.Printf(" - %s", stat.Stat.Name)
+		result.Stats = append(result.Stats, statInfo{Name: stat.Stat.Name, BaseStat: stat.BaseStat, IV: owned.IVs.Get(stat.Stat.Name), EV: owned.EVs.Get(stat.Stat.Name)})
+	}
+	result.CaughtAt = owned.CaughtAt
+	if !owned.CaughtOn.IsZero() { //pokemon from before catch times were saved
+		result.CaughtOn = &owned.CaughtOn
 	}
-	return nil
+	return result, nil
 }
 
-func commandPokedex(cfg *config, args ...string) error {
-	if len(cfg.user.CaughtPokemon) <= 0 {
-		return fmt.Errorf("You have not caught any pokemon")
-	}
-	fmt.Printf("Your pokedex:\n")
-	for _, pokemon := range cfg.user.CaughtPokemon {
-		fmt.Printf(" - %s\n", pokemon.Name)
+func commandPokedex(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	result := pokedexResult{Pokemon: []pokedexEntry{}} //an empty pokedex is still a pokedex, json and yaml show an empty list
+
+	for _, owned := range cfg.user.CaughtPokemon { //already in catch order
+		entry := pokedexEntry{ID: owned.ID, Name: owned.Species, Level: owned.Level, Types: []string{}}
+		for _, kind := range owned.Data.Types {
+			entry.Types = append(entry.Types, kind.Type.Name)
+		}
+		result.Pokemon = append(result.Pokemon, entry)
 	}
-	return nil
+	return result, nil
 }
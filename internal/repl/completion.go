@@ -0,0 +1,127 @@
+package repl
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// completeLine splits the line around the word under the cursor and offers candidates for it, in the shape liner's WordCompleter wants.
+// pos counts runes, not bytes, like everything else liner hands over.
+func completeLine(cfg *config, line string, pos int) (string, []string, string) {
+	runes := []rune(line)
+	pos = min(max(pos, 0), len(runes))
+	before, after := string(runes[:pos]), string(runes[pos:])
+	words := strings.Fields(before)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(before, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	head := before[:len(before)-len(partial)]
+
+	var candidates []string
+	if len(words) == 0 {
+		candidates = commandNames()
+	} else if command, exists := commandDictionary[strings.ToLower(words[0])]; exists && command.complete != nil {
+		candidates = command.complete(cfg, words[1:])
+	}
+
+	completions := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, strings.ToLower(partial)) {
+			completions = append(completions, candidate+" ")
+		}
+	}
+	return head, completions, after
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commandDictionary))
+	for name := range commandDictionary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// onlyFirstArg wraps a completer that only makes sense for the first positional arg
+func onlyFirstArg(candidates func(cfg *config) []string) func(*config, []string) []string {
+	return func(cfg *config, prior []string) []string {
+		if len(prior) > 0 {
+			return nil
+		}
+		return candidates(cfg)
+	}
+}
+
+func completeCommands(cfg *config) []string {
+	return commandNames()
+}
+
+func completeMapLocations(cfg *config) []string {
+	return cfg.lastMapLocations
+}
+
+func completeAreaMethods(cfg *config) []string {
+	return cfg.areaMethods
+}
+
+func completeVersion(cfg *config) []string {
+	return []string{"all"} //PokeAPI has dozens of versions, listing them would mean a request on every tab
+}
+
+func completeWildPokemon(cfg *config) []string {
+	if cfg.wild == nil {
+		return nil
+	}
+	return []string{cfg.wild.Pokemon}
+}
+
+func completeCaughtPokemon(cfg *config) []string {
+	names := make([]string, 0, len(cfg.user.CaughtPokemon))
+	for _, owned := range cfg.user.CaughtPokemon {
+		if !slices.Contains(names, owned.Species) { //two of a species complete to one name, inspect lists their numbers
+			names = append(names, owned.Species)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completeProfile(cfg *config, prior []string) []string {
+	switch {
+	case len(prior) == 0:
+		return []string{"delete", "list", "new", "switch"}
+	case len(prior) == 1 && (prior[0] == "switch" || prior[0] == "delete") && cfg.profiles != nil:
+		names, _ := cfg.profiles.List()
+		return names
+	}
+	return nil
+}
+
+func completeCache(cfg *config, prior []string) []string {
+	switch {
+	case len(prior) == 0:
+		return []string{"clear", "evict", "list", "show", "stats"}
+	case len(prior) == 1 && (prior[0] == "show" || prior[0] == "evict"):
+		keys := []string{}
+		for _, entry := range cfg.cache.Entries() {
+			keys = append(keys, entry.Key)
+		}
+		return keys
+	}
+	return nil
+}
+
+func completeSnapshot(cfg *config, prior []string) []string {
+	switch {
+	case len(prior) == 0:
+		return []string{"area", "pokemon", "region", "version"}
+	case len(prior) == 1 && prior[0] == "area":
+		return cfg.lastMapLocations
+	case len(prior) == 1 && prior[0] == "pokemon":
+		return cfg.areaPokemon
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/atomicfile"
+	"github.com/peterh/liner"
+	"io"
+	"os"
+	"strings"
+)
+
+var errInterrupted = errors.New("interrupted") //Ctrl-C at the prompt
+
+// lineReader is where the REPL gets its input, io.EOF means there is no more
+type lineReader interface {
+	readLine(prompt string) (string, error)
+	close() error
+}
+
+// scannerReader is used when stdin is a pipe or file, there is nothing to edit and no one to complete for
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer //where the prompt goes
+}
+
+func newScannerReader(r io.Reader, out io.Writer) *scannerReader {
+	return &scannerReader{scanner: bufio.NewScanner(r), out: out}
+}
+
+func (r *scannerReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if r.scanner.Scan() {
+		return r.scanner.Text(), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+func (r *scannerReader) close() error {
+	return nil
+}
+
+// editorReader gives a terminal arrow key editing, history with Ctrl-R search, and tab completion
+type editorReader struct {
+	state       *liner.State
+	historyFile string //empty keeps history for this session only
+}
+
+func newEditorReader(cfg *config, historyFile string) *editorReader {
+	state := liner.NewLiner()
+	state.SetCtrlCAborts(true) //Ctrl-C comes back as an error so the REPL can treat it like any other interrupt
+	state.SetTabCompletionStyle(liner.TabPrints)
+	state.SetWordCompleter(func(line string, pos int) (string, []string, string) {
+		return completeLine(cfg, line, pos)
+	})
+
+	if historyFile != "" {
+		if f, err := os.Open(historyFile); err == nil {
+			state.ReadHistory(f)
+			f.Close()
+		}
+	}
+	return &editorReader{state: state, historyFile: historyFile}
+}
+
+func (r *editorReader) readLine(prompt string) (string, error) {
+	line, err := r.state.Prompt(prompt)
+	if errors.Is(err, liner.ErrPromptAborted) {
+		return "", errInterrupted
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(line) != "" {
+		r.state.AppendHistory(line)
+	}
+	return line, nil
+}
+
+// close saves the history and hands the terminal back in the mode we found it
+func (r *editorReader) close() error {
+	defer r.state.Close()
+	if r.historyFile == "" {
+		return nil
+	}
+	var history bytes.Buffer
+	if _, err := r.state.WriteHistory(&history); err != nil {
+		return err
+	}
+	return atomicfile.Write(r.historyFile, history.Bytes())
+}
@@ -0,0 +1,63 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+const (
+	ExitOK      = 0
+	ExitFailed  = 1 //the command ran and returned an error
+	ExitUsage   = 2 //unknown command or bad arguments, nothing ran
+	ExitStartup = 3 //the session itself could not be set up
+)
+
+// RunCommand runs a single command from the shell, eg `pokedexcli explore canalave-city-area`, and returns the exit code.
+// The active profile is loaded first and saved after, so a series of invocations plays like one session.
+func RunCommand(opts Options, args []string) int {
+	cfg, err := setup(opts)
+	if err != nil {
+		fmt.Fprintln(orDefault(opts.Stderr, io.Writer(os.Stderr)), err)
+		return ExitStartup
+	}
+	defer cfg.shutdown()
+
+	commandName := strings.ToLower(args[0])
+	command, exists := commandDictionary[commandName]
+	if !exists {
+		fmt.Fprintf(cfg.errOut, "Unknown command: %s. Run `pokedexcli help` to see available commands.\n", commandName)
+		return ExitUsage
+	}
+
+	interrupts := make(chan os.Signal, 1) //Ctrl-C cancels the command, then we still save on the way out
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+			cfg.interrupts.interrupt()
+		}
+	}()
+
+	err = runCommand(cfg, command, args[1:])
+	var usage *usageErr
+	var scriptExit *scriptExitError
+	switch {
+	case errors.As(err, &scriptExit):
+		fmt.Fprintln(cfg.errOut, err)
+		cfg.render(goodbye)
+		return ExitFailed
+	case errors.Is(err, errExit):
+		cfg.render(goodbye)
+	case errors.As(err, &usage):
+		fmt.Fprintln(cfg.errOut, err)
+		return ExitUsage
+	case err != nil:
+		fmt.Fprintln(cfg.errOut, err)
+		return ExitFailed
+	}
+	return ExitOK
+}
@@ -0,0 +1,197 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"slices"
+	"strings"
+)
+
+type argSpec struct {
+	name     string
+	optional bool //optional args must come after the required ones
+	variadic bool //soaks up every remaining arg, only valid on the last spec
+	keepCase bool //file paths and the like, everything else is lowercased like the rest of the input
+}
+
+type flagSpec struct {
+	name       string //long form, used as --name
+	short      string //single letter used as -s, may be empty
+	takesValue bool   //false means a boolean switch
+	usage      string
+}
+
+// tokenize splits a line on whitespace, keeping single or double quoted text together and honoring backslash escapes
+func tokenize(line string) ([]string, error) {
+	tokens := []string{}
+	var current strings.Builder
+	inToken := false
+	var quote rune //the quote we are inside of, 0 when not quoted
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'': //everything is literal inside single quotes, like a shell
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true //so "" still counts as an empty argument
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("Line ends with an unfinished escape")
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// parseArgs checks tokens against the command's schema, returning positional args and the flags that were set.
+// Boolean flags are stored as "true" so callers only ever need to check if a flag exists.
+func parseArgs(command cliCommand, tokens []string) ([]string, map[string]string, error) {
+	positional := []string{}
+	flags := make(map[string]string)
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "--" { //everything after -- is positional, even if it starts with a dash
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(token, "-") || token == "-" {
+			positional = append(positional, token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(token, "-"), "=")
+		spec, exists := command.lookupFlag(strings.ToLower(name), strings.HasPrefix(token, "--"))
+		if !exists {
+			return nil, nil, usageError(command, fmt.Sprintf("Unknown option %s", token))
+		}
+		switch {
+		case !spec.takesValue && hasValue:
+			return nil, nil, usageError(command, fmt.Sprintf("Option --%s does not take a value", spec.name))
+		case !spec.takesValue:
+			value = "true"
+		case !hasValue:
+			if i+1 >= len(tokens) {
+				return nil, nil, usageError(command, fmt.Sprintf("Option --%s needs a value", spec.name))
+			}
+			i++
+			value = tokens[i]
+		}
+		flags[spec.name] = value
+	}
+
+	required, max := 0, 0
+	for _, arg := range command.args {
+		if !arg.optional && !arg.variadic {
+			required++
+		}
+		max++
+		if arg.variadic {
+			max = -1
+			break
+		}
+	}
+	if len(positional) < required {
+		return nil, nil, usageError(command, fmt.Sprintf("Missing %s", command.args[len(positional)].name))
+	}
+	if max >= 0 && len(positional) > max {
+		return nil, nil, usageError(command, fmt.Sprintf("Too many arguments, %s takes at most %d", command.name, max))
+	}
+
+	for i := range positional {
+		spec := command.args[min(i, len(command.args)-1)] //variadic args share the last spec
+		if !spec.keepCase {
+			positional[i] = strings.ToLower(positional[i])
+		}
+	}
+	return positional, flags, nil
+}
+
+// globalFlags are accepted by every command, they change how the pokedex behaves rather than what the command does
+var globalFlags = []flagSpec{
+	{name: "output", short: "o", takesValue: true, usage: "how to show the result: " + outputFormatList()},
+}
+
+func outputFormatList() string {
+	names := make([]string, len(render.Formats))
+	for i, format := range render.Formats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, "|")
+}
+
+func (c cliCommand) lookupFlag(name string, long bool) (flagSpec, bool) {
+	for _, spec := range slices.Concat(c.flags, globalFlags) {
+		if (long && spec.name == name) || (!long && spec.short != "" && spec.short == name) {
+			return spec, true
+		}
+	}
+	return flagSpec{}, false
+}
+
+// usage builds the one line synopsis for a command from its schema, eg `inspect <pokemon-name> [-f|--full]`
+func (c cliCommand) usage() string {
+	parts := []string{c.name}
+	for _, arg := range c.args {
+		switch {
+		case arg.variadic:
+			parts = append(parts, "["+arg.name+"...]")
+		case arg.optional:
+			parts = append(parts, "["+arg.name+"]")
+		default:
+			parts = append(parts, "<"+arg.name+">")
+		}
+	}
+	for _, spec := range c.flags {
+		flag := "--" + spec.name
+		if spec.short != "" {
+			flag = "-" + spec.short + "|" + flag
+		}
+		if spec.takesValue {
+			flag += " <value>"
+		}
+		parts = append(parts, "["+flag+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// usageErr marks a command line that never reached the command, so one shot mode can exit with a usage status
+type usageErr struct {
+	problem string
+	usage   string
+}
+
+func (e *usageErr) Error() string {
+	return fmt.Sprintf("%s. Usage: %s", e.problem, e.usage)
+}
+
+func usageError(command cliCommand, problem string) error {
+	return &usageErr{problem: problem, usage: command.usage()}
+}
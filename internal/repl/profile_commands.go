@@ -0,0 +1,110 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"os"
+)
+
+const profileUsage = "Usage: profile <list|new <name>|switch <name>|delete <name>>"
+
+func commandProfile(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if len(args) == 0 {
+		return message("Active profile: %s\n%s", cfg.user.Name, profileUsage), nil
+	}
+	if cfg.profiles == nil {
+		return nil, fmt.Errorf("Profiles are unavailable without a profile directory, only %s can be used this session.", cfg.user.Name)
+	}
+
+	switch args[0] {
+	case "list":
+		names, err := cfg.profiles.List()
+		if err != nil {
+			return nil, fmt.Errorf("Error listing profiles: %w", err)
+		}
+		return profilesResult{Active: cfg.user.Name, Profiles: names}, nil
+	case "new", "switch", "delete":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("Please provide a profile name. Usage: profile %s <name>", args[0])
+		}
+	default:
+		return nil, fmt.Errorf("Unknown profile subcommand: %s\n%s", args[0], profileUsage)
+	}
+
+	name := args[1]
+	switch args[0] {
+	case "new":
+		if err := cfg.profiles.Create(name); err != nil {
+			return nil, fmt.Errorf("Error creating profile: %w", err)
+		}
+		if err := switchProfile(cfg, name); err != nil {
+			return nil, fmt.Errorf("Created profile %s, but could not switch to it: %w", name, err)
+		}
+		return message("Created profile %s.\nSwitched to profile %s.", name, name), nil
+	case "switch":
+		if name == cfg.user.Name {
+			return message("%s is already the active profile.", name), nil
+		}
+		if !cfg.profiles.Exists(name) {
+			return nil, fmt.Errorf("There is no profile named %s. Use `profile new %s` to create it.", name, name)
+		}
+		if err := switchProfile(cfg, name); err != nil {
+			return nil, err
+		}
+		return message("Switched to profile %s, %d caught pokemon.", name, len(cfg.user.CaughtPokemon)), nil
+	default: //delete
+		if name == cfg.user.Name {
+			return nil, fmt.Errorf("Cannot delete the active profile, switch to another one first.")
+		}
+		if err := cfg.profiles.Delete(name); err != nil {
+			return nil, fmt.Errorf("Error deleting profile: %w", err)
+		}
+		return message("Deleted profile %s.", name), nil
+	}
+}
+
+// switchProfile saves the current trainer, then swaps in the named one with a fresh session so nothing carries over
+func switchProfile(cfg *config, name string) error {
+	if cfg.user != nil {
+		if err := saveGame(cfg, cfg.saveFile); err != nil {
+			return fmt.Errorf("Error saving %s before switching: %w", cfg.user.Name, err)
+		}
+	}
+	user, err := actors.NewUser(name)
+	if err != nil {
+		return err
+	}
+
+	resetSession(cfg)
+	cfg.user = user
+	cfg.saveFile = ""
+	if cfg.profiles == nil {
+		return nil
+	}
+	cfg.saveFile = cfg.profiles.Path(name)
+
+	if err := loadGame(cfg, cfg.saveFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(cfg.errOut, "Could not load the save for %s, starting a new game: %v\n", name, err)
+	}
+	if err := cfg.profiles.SetActive(name); err != nil {
+		fmt.Fprintf(cfg.errOut, "Could not remember %s as the active profile: %v\n", name, err)
+	}
+	return nil
+}
+
+// resetSession clears everything in config that belongs to one trainer's game
+func resetSession(cfg *config) {
+	cfg.nextLocationPage = 0
+	cfg.previousLocationPage = -1
+	cfg.currentLocation = ""
+	cfg.currentLocationURL = ""
+	cfg.lastMapLocations = nil
+	cfg.areaPokemon = nil
+	cfg.areaMethods = nil
+	cfg.wild = nil
+	cfg.version = nil
+	cfg.versionLocations = nil
+}
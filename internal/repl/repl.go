@@ -2,33 +2,102 @@ package repl
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/CSelvidge/pokedexcli/internal/pokecache"
 	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"github.com/CSelvidge/pokedexcli/internal/pokecache"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"io"
+	"math/rand/v2"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var commandDictionary = make(map[string]cliCommand)
 
-func Start(cache *pokecache.Cache, user *actors.User) {
-	fmt.Println("Welcome to the Pokedex!")
-	fmt.Println("Type 'help' to see available commands.")
-	initMap()
-	cfg := newConfig(cache, user)
+// Options is everything main hands the REPL, an empty CacheFile or nil Profiles turns off that kind of persistence
+type Options struct {
+	Client      *pokeapi.Client
+	Cache       *pokecache.Cache
+	CacheFile   string
+	Profiles    *actors.ProfileStore
+	Profile     string            //profile to start with, empty picks the one used last
+	Interactive bool              //stdin is a terminal, turns on line editing and tab completion
+	HistoryFile string            //where line editor history is kept between sessions
+	Output      render.Format     //how command results are shown, empty means text
+	Snapshot    *pokeapi.Snapshot //where the snapshot command saves data for offline use, nil disables it
+	Offline     bool              //Client reads from the snapshot, so there is nothing to download
+	Seed        uint64            //seeds catches and encounters so a session can be replayed, 0 picks a random seed
+	Now         func() time.Time  //stamps caught pokemon, nil means time.Now
+	Stdin       io.Reader         //nil means os.Stdin, tests swap these out to drive a session
+	Stdout      io.Writer         //nil means os.Stdout
+	Stderr      io.Writer         //nil means os.Stderr, only one shot commands write errors here
+}
+
+func Start(opts Options) {
+	out := orDefault(opts.Stdout, io.Writer(os.Stdout))
+	fmt.Fprintln(out, "Welcome to the Pokedex!")
+	fmt.Fprintln(out, "Type 'help' to see available commands.")
+	if opts.Offline && opts.Snapshot != nil {
+		fmt.Fprintf(out, "Offline, reading PokeAPI data from %s\n", opts.Snapshot.Dir())
+	}
+	cfg, err := setup(opts)
+	if err != nil {
+		fmt.Fprintf(out, "%v\n", err)
+		return
+	}
+	if opts.Interactive {
+		cfg.input = newEditorReader(cfg, opts.HistoryFile)
+	}
+	cfg.onShutdown("history", cfg.input.close) //after the hooks above are registered so it runs last, giving the terminal back
+	handleSignals(cfg)
 	getUserInput(cfg)
+	cfg.shutdown() //stdin closed, still run cleanup before main returns
+}
+
+// setup builds the session both the REPL and one shot commands run in: active profile loaded and save/cache hooks registered
+func setup(opts Options) (*config, error) {
+	initMap()
+	cfg := newConfig(opts)
+	profile := opts.Profile
+	if profile == "" && cfg.profiles != nil {
+		profile = cfg.profiles.Active()
+	}
+	if profile == "" {
+		profile = actors.DefaultProfile
+	}
+	if err := switchProfile(cfg, profile); err != nil {
+		return nil, fmt.Errorf("Error starting profile %s: %w", profile, err)
+	}
+	cfg.onShutdown("save game", func() error {
+		return saveGame(cfg, cfg.saveFile)
+	})
+	cfg.onShutdown("cache", func() error {
+		if cfg.cache == nil {
+			return nil
+		}
+		cfg.cache.Close()
+		return saveCache(cfg)
+	})
+	return cfg, nil
 }
 
 func initMap() {
-	commandDictionary["exit"] = cliCommand{
+	commandDictionary["exit"] = cliCommand{ //handled by whoever runs the command, see errExit
 		name:        "exit",
 		description: "Exit the Pokedex CLI",
 		callback:    commandExit,
 	}
 	commandDictionary["help"] = cliCommand{
 		name:        "help",
-		description: "List all available commands",
+		description: "List all available commands, or show how to use one",
+		args:        []argSpec{{name: "command", optional: true}},
+		complete:    onlyFirstArg(completeCommands),
 		callback:    commandHelp,
 	}
 	commandDictionary["map"] = cliCommand{
@@ -43,43 +112,150 @@ func initMap() {
 	}
 	commandDictionary["explore"] = cliCommand{
 		name:        "explore",
-		description: "Explore location to find Pokemon! Usage is `explore <location-name>`",
+		description: "Explore location to find Pokemon!",
+		args:        []argSpec{{name: "location-name"}},
+		complete:    onlyFirstArg(completeMapLocations),
 		callback:    commandExplore,
 	}
 	commandDictionary["catch"] = cliCommand{
 		name:        "catch",
-		description: "Catch a Pokemon! Usage is `catch <pokemon-name>`",
-		callback:    commandCatch,
+		description: "Catch a Pokemon! Low HP, a status condition and a better ball all make it easier",
+		args:        []argSpec{{name: "pokemon-name", optional: true}},
+		flags: []flagSpec{
+			{name: "ball", short: "b", takesValue: true, usage: "ball to throw: poke, great, ultra or master"},
+			{name: "status", short: "s", takesValue: true, usage: "the pokemon's status: none, sleep, freeze, paralysis, poison or burn"},
+			{name: "hp", takesValue: true, usage: "percentage of HP the pokemon has left, 1 to 100"},
+		},
+		complete: onlyFirstArg(completeWildPokemon),
+		callback: commandCatch,
+	}
+	commandDictionary["encounter"] = cliCommand{
+		name:        "encounter",
+		description: "Look for a wild pokemon in the explored area, by walk unless another method like surf or old-rod is given",
+		args:        []argSpec{{name: "method", optional: true}},
+		flags:       []flagSpec{{name: "version", short: "v", takesValue: true, usage: "game whose encounter table is used, eg diamond"}},
+		complete:    onlyFirstArg(completeAreaMethods),
+		callback:    commandEncounter,
+	}
+	commandDictionary["version"] = cliCommand{
+		name:        "version",
+		description: "Show the game being played, or pick one so map, explore, encounter and inspect only show its data, all shows every game",
+		args:        []argSpec{{name: "name", optional: true}},
+		complete:    onlyFirstArg(completeVersion),
+		callback:    commandVersion,
+	}
+	commandDictionary["seed"] = cliCommand{
+		name:        "seed",
+		description: "Show the random seed for this session, or restart the random numbers from a new one",
+		args:        []argSpec{{name: "number", optional: true}},
+		callback:    commandSeed,
 	}
 	commandDictionary["inspect"] = cliCommand{
-		name: "inspect",
-		description: "Brief inspection of caught pokemon Usage is `inspect <pokemon-name>`",
-		callback: commandInspect,
+		name:        "inspect",
+		description: "Brief inspection of a caught pokemon by name or pokedex number like #2, --full also shows types, stats, IVs and where it was caught",
+		args:        []argSpec{{name: "pokemon"}},
+		flags:       []flagSpec{{name: "full", short: "f", usage: "show types, stats, IVs and where it was caught too"}},
+		complete:    onlyFirstArg(completeCaughtPokemon),
+		callback:    commandInspect,
 	}
 	commandDictionary["fullinspect"] = cliCommand{
-		name: "fullinspect",
-		description: "Inspect all stored stats for caught pokemon. Usage is same as inspect.",
-		callback: commandFullInspect,
+		name:        "fullinspect",
+		description: "Inspect all stored stats for a caught pokemon, same as inspect --full",
+		args:        []argSpec{{name: "pokemon"}},
+		complete:    onlyFirstArg(completeCaughtPokemon),
+		callback:    commandFullInspect,
 	}
 	commandDictionary["pokedex"] = cliCommand{
-		name: "pokedex",
-		description: "list all caught pokemon",
-		callback: commandPokedex,
+		name:        "pokedex",
+		description: "list all caught pokemon with their pokedex numbers and levels",
+		callback:    commandPokedex,
+	}
+	commandDictionary["save"] = cliCommand{
+		name:        "save",
+		description: "Save your caught pokemon and location, progress is also saved on exit",
+		args:        []argSpec{{name: "file", optional: true, keepCase: true}},
+		callback:    commandSave,
+	}
+	commandDictionary["load"] = cliCommand{
+		name:        "load",
+		description: "Load a saved game, replacing the current one",
+		args:        []argSpec{{name: "file", optional: true, keepCase: true}},
+		callback:    commandLoad,
+	}
+	commandDictionary["profile"] = cliCommand{
+		name:        "profile",
+		description: "Manage trainer profiles: list, new <name>, switch <name> or delete <name>",
+		args:        []argSpec{{name: "list|new|switch|delete", optional: true}, {name: "name", optional: true}},
+		complete:    completeProfile,
+		callback:    commandProfile,
+	}
+	commandDictionary["run"] = cliCommand{
+		name:        "run",
+		description: "Run the commands in a file line by line, # starts a comment",
+		args:        []argSpec{{name: "file", keepCase: true}},
+		flags: []flagSpec{
+			{name: "stop-on-error", short: "e", usage: "stop at the first command that fails, same as `set -e` in the file"},
+			{name: "echo", short: "x", usage: "print each command before running it, same as `set -x` in the file"},
+		},
+		callback: commandRun,
+	}
+	commandDictionary["snapshot"] = cliCommand{
+		name:        "snapshot",
+		description: "Download a region, area, pokemon or game version for --offline use, regions include every area, pokemon and version in them",
+		args:        []argSpec{{name: "region|area|pokemon|version"}, {name: "name"}},
+		complete:    completeSnapshot,
+		callback:    commandSnapshot,
+	}
+	commandDictionary["cache"] = cliCommand{
+		name:        "cache",
+		description: "Inspect the request cache: stats, list, show <key>, evict <key> or clear",
+		args:        []argSpec{{name: "stats|list|show|evict|clear"}, {name: "key", optional: true, keepCase: true}},
+		complete:    completeCache,
+		callback:    commandCache,
 	}
 }
 
-func newConfig(cache *pokecache.Cache, user *actors.User) *config {
+func newConfig(opts Options) *config {
 	cfg := &config{
-		nextLocationsURL:     "",
-		previousLocationsURL: "",
+		nextLocationPage:     0,
+		previousLocationPage: -1,
 		currentLocation:      "",
 		currentLocationURL:   "",
-		cache:                cache,
-		user:                 user,
+		client:               opts.Client,
+		cache:                opts.Cache,
+		cacheFile:            opts.CacheFile,
+		profiles:             opts.Profiles,
+		output:               opts.Output,
+		snapshot:             opts.Snapshot,
+		offline:              opts.Offline,
+		out:                  orDefault(opts.Stdout, io.Writer(os.Stdout)),
+		errOut:               orDefault(opts.Stderr, io.Writer(os.Stderr)),
+		shutdownOnce:         &sync.Once{},
+		interrupts:           &interruptHandler{},
+	}
+	cfg.input = newScannerReader(orDefault(opts.Stdin, io.Reader(os.Stdin)), cfg.out)
+	cfg.reseed(orDefault(opts.Seed, rand.Uint64()))
+	cfg.now = opts.Now
+	if cfg.now == nil {
+		cfg.now = time.Now
 	}
 	return cfg
 }
 
+// reseed restarts the session's random numbers from seed, the same seed and the same commands give the same catches
+func (cfg *config) reseed(seed uint64) {
+	cfg.seed = seed
+	cfg.rng = rand.New(rand.NewPCG(seed, seed))
+}
+
+func orDefault[T comparable](value, fallback T) T {
+	var zero T
+	if value == zero {
+		return fallback
+	}
+	return value
+}
+
 func GetCacheSettings() (Type string, Life int) {
 	durationType := ""
 	durationLife := 0
@@ -136,32 +312,57 @@ func GetCacheSettings() (Type string, Life int) {
 	return durationType, durationLife
 }
 
+// getUserInput runs commands until exit, EOF or a second Ctrl-C, shutting down is left to the caller
 func getUserInput(cfg *config) {
-	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Printf("Pokedex >")
-	for scanner.Scan() {
-		input := cleanInput(scanner.Text())
+	for {
+		line, err := cfg.input.readLine(prompt(cfg))
+		if errors.Is(err, errInterrupted) {
+			if cfg.interrupts.interrupt() == interruptExit {
+				cfg.render(goodbye)
+				return
+			}
+			fmt.Fprintln(cfg.out, "(press Ctrl-C again or Ctrl-D to exit)")
+			continue
+		}
+		if err != nil {
+			fmt.Fprintln(cfg.out) //EOF, leave the shell prompt on its own line
+			return
+		}
+
+		input, err := tokenize(line)
+		if err != nil {
+			fmt.Fprintf(cfg.out, "%v\n", err)
+			continue
+		}
 		if len(input) == 0 {
-			fmt.Println("Please enter at least one character")
-			fmt.Printf("Pokedex >")
+			fmt.Fprintln(cfg.out, "Please enter at least one character")
 			continue
 		}
 
-		commandName := input[0]
-		commandArgs := input[1:] //Future proofing for multiple arguments
+		commandName := strings.ToLower(input[0])
+		commandArgs := input[1:]
 
 		command, exists := commandDictionary[commandName]
 
 		if !exists {
-			fmt.Printf("Unknown command: %s\n", commandName)
-			fmt.Printf("Pokedex >")
+			fmt.Fprintf(cfg.out, "Unknown command: %s\n", commandName)
 			continue
 		}
-		executeCommand(cfg, command, commandArgs)
-		fmt.Printf("Pokedex >")
+		if executeCommand(cfg, command, commandArgs) {
+			cfg.render(goodbye)
+			return
+		}
 	}
 }
 
+// prompt shows the active profile so it is obvious whose pokedex is being used
+func prompt(cfg *config) string {
+	if cfg.user == nil {
+		return "Pokedex >"
+	}
+	return fmt.Sprintf("Pokedex (%s) >", cfg.user.Name)
+}
+
 func cleanInput(input string) []string {
 	lowered := strings.ToLower(input)
 	trimmed := strings.TrimSpace(lowered)
@@ -170,14 +371,60 @@ func cleanInput(input string) []string {
 	return words
 }
 
-func executeCommand(cfg *config, command cliCommand, args []string) { //input sanitized in function that called, so we know command is valid
-	var arguments string //if multiple aruguments are needed in the future this will need to be changed to a slice
-	if len(args) > 0 {
-		arguments = args[0] //should only ever get 1 argument, but this allows for easier future proofing if more args are needed
+// executeCommand runs a command the trainer typed and reports whether it asked to exit, command is known to exist, its args still need checking
+func executeCommand(cfg *config, command cliCommand, tokens []string) bool {
+	err := runCommand(cfg, command, tokens)
+	var scriptExit *scriptExitError
+	if errors.As(err, &scriptExit) {
+		fmt.Fprintf(cfg.out, "%v\n", err)
+	}
+	if errors.Is(err, errExit) {
+		return true
 	}
+	if err != nil {
+		fmt.Fprintf(cfg.out, "%v\n", err)
+	}
+	return false
+}
+
+// runCommand checks the args against the command's schema and runs it, a *usageErr means the callback never ran
+func runCommand(cfg *config, command cliCommand, tokens []string) error {
+	ctx, done := cfg.interrupts.commandContext()
+	defer done()
 
-	err := command.callback(cfg, arguments) //functions are variadic, so arguments can be empty
+	return runCommandContext(ctx, cfg, command, tokens)
+}
+
+// runCommandContext is runCommand for commands run by a script, they share the script's context instead of getting their own
+func runCommandContext(ctx context.Context, cfg *config, command cliCommand, tokens []string) error {
+	args, flags, err := parseArgs(command, tokens)
 	if err != nil {
-		fmt.Printf("%v\n", err)
+		return err
+	}
+	format := cfg.output
+	if raw, exists := flags["output"]; exists {
+		if format, err = render.ParseFormat(raw); err != nil {
+			return usageError(command, err.Error())
+		}
 	}
+	cfg.flags = flags
+
+	result, err := command.callback(ctx, cfg, args...) //functions are variadic, so arguments can be empty
+	if err != nil || result == nil {
+		return err
+	}
+	return render.Write(cfg.out, format, result)
+}
+
+// render shows a result in the session's output format, for the few places that report something outside of a command's result
+func (cfg *config) render(result render.Result) {
+	if err := render.Write(cfg.out, cfg.output, result); err != nil {
+		fmt.Fprintf(cfg.out, "Error showing result: %v\n", err)
+	}
+}
+
+// hasFlag reports whether a boolean option was passed to the running command
+func (cfg *config) hasFlag(name string) bool {
+	_, exists := cfg.flags[name]
+	return exists
 }
@@ -1,7 +1,16 @@
 package repl
 
 import (
+	"context"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/encounter"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 func TestCleanInput(t *testing.T) {
@@ -45,3 +54,362 @@ func TestCleanInput(t *testing.T) {
 		}
 	}
 }
+
+func TestInterruptHandler(t *testing.T) {
+	h := &interruptHandler{}
+
+	if action := h.interrupt(); action != interruptArmed {
+		t.Errorf("expected first Ctrl-C at the prompt to warn, got %v", action)
+	}
+	ctx, done := h.commandContext()
+	if action := h.interrupt(); action != interruptCancelled {
+		t.Errorf("expected Ctrl-C during a command to cancel it, got %v", action)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("expected command context to be cancelled")
+	}
+	done()
+	if action := h.interrupt(); action != interruptExit {
+		t.Errorf("expected second Ctrl-C to exit, got %v", action)
+	}
+
+	h = &interruptHandler{}
+	h.interrupt()
+	_, done = h.commandContext() //running a command disarms the pending exit
+	done()
+	if action := h.interrupt(); action != interruptArmed {
+		t.Errorf("expected Ctrl-C after a new command to warn again, got %v", action)
+	}
+}
+
+func TestInterruptHandlerStop(t *testing.T) {
+	h := &interruptHandler{}
+	ctx, done := h.commandContext()
+	stopped := make(chan struct{})
+	go func() {
+		h.stop()
+		close(stopped)
+	}()
+
+	<-ctx.Done() //stop cancels the command first
+	select {
+	case <-stopped:
+		t.Fatalf("expected stop to wait for the running command to return")
+	case <-time.After(20 * time.Millisecond):
+	}
+	done()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("expected stop to return once the command did")
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{input: "  explore   canalave-city-area ", expected: []string{"explore", "canalave-city-area"}},
+		{input: `save "my saves/ash.json"`, expected: []string{"save", "my saves/ash.json"}},
+		{input: `save 'it''s here'`, expected: []string{"save", "its here"}},
+		{input: `say "a \"quoted\" word"`, expected: []string{"say", `a "quoted" word`}},
+		{input: `path 'C:\saves'`, expected: []string{"path", `C:\saves`}},
+		{input: `empty ""`, expected: []string{"empty", ""}},
+		{input: "", expected: []string{}},
+		{input: `save "never closed`, wantErr: true},
+		{input: `trailing \`, wantErr: true},
+	}
+
+	for _, c := range cases {
+		actual, err := tokenize(c.input)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("expected an error tokenizing %q", c.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error tokenizing %q: %v", c.input, err)
+			continue
+		}
+		if strings.Join(actual, "|") != strings.Join(c.expected, "|") || len(actual) != len(c.expected) {
+			t.Errorf("tokenizing %q, expected %q, received %q", c.input, c.expected, actual)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	inspect := cliCommand{
+		name:  "inspect",
+		args:  []argSpec{{name: "pokemon-name"}},
+		flags: []flagSpec{{name: "full", short: "f"}, {name: "output", short: "o", takesValue: true}},
+	}
+	save := cliCommand{
+		name: "save",
+		args: []argSpec{{name: "file", optional: true, keepCase: true}},
+	}
+
+	cases := []struct {
+		command   cliCommand
+		tokens    []string
+		wantArgs  []string
+		wantFlags map[string]string
+		wantErr   string
+	}{
+		{command: inspect, tokens: []string{"Pikachu"}, wantArgs: []string{"pikachu"}, wantFlags: map[string]string{}},
+		{command: inspect, tokens: []string{"-f", "pikachu"}, wantArgs: []string{"pikachu"}, wantFlags: map[string]string{"full": "true"}},
+		{command: inspect, tokens: []string{"pikachu", "--output", "json"}, wantArgs: []string{"pikachu"}, wantFlags: map[string]string{"output": "json"}},
+		{command: inspect, tokens: []string{"--output=yaml", "pikachu"}, wantArgs: []string{"pikachu"}, wantFlags: map[string]string{"output": "yaml"}},
+		{command: inspect, tokens: []string{"--", "-weird-"}, wantArgs: []string{"-weird-"}, wantFlags: map[string]string{}},
+		{command: save, tokens: []string{"Saves/Ash.json"}, wantArgs: []string{"Saves/Ash.json"}, wantFlags: map[string]string{}},
+		{command: save, tokens: []string{}, wantArgs: []string{}, wantFlags: map[string]string{}},
+		{command: save, tokens: []string{"-o", "json"}, wantArgs: []string{}, wantFlags: map[string]string{"output": "json"}}, //global flag, save doesn't declare it
+		{command: inspect, tokens: []string{}, wantErr: "Missing pokemon-name. Usage: inspect <pokemon-name> [-f|--full] [-o|--output <value>]"},
+		{command: inspect, tokens: []string{"a", "b"}, wantErr: "Too many arguments"},
+		{command: inspect, tokens: []string{"pikachu", "--shiny"}, wantErr: "Unknown option --shiny"},
+		{command: inspect, tokens: []string{"pikachu", "-o"}, wantErr: "Option --output needs a value"},
+		{command: inspect, tokens: []string{"pikachu", "--full=yes"}, wantErr: "Option --full does not take a value"},
+		{command: save, tokens: []string{"a", "b"}, wantErr: "Usage: save [file]"},
+	}
+
+	for _, c := range cases {
+		args, flags, err := parseArgs(c.command, c.tokens)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("parsing %q for %s, expected error containing %q, got %v", c.tokens, c.command.name, c.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", c.tokens, err)
+			continue
+		}
+		if strings.Join(args, "|") != strings.Join(c.wantArgs, "|") || len(args) != len(c.wantArgs) {
+			t.Errorf("parsing %q, expected args %q, received %q", c.tokens, c.wantArgs, args)
+		}
+		if len(flags) != len(c.wantFlags) {
+			t.Errorf("parsing %q, expected flags %v, received %v", c.tokens, c.wantFlags, flags)
+		}
+		for name, value := range c.wantFlags {
+			if flags[name] != value {
+				t.Errorf("parsing %q, expected --%s=%q, received %q", c.tokens, name, value, flags[name])
+			}
+		}
+	}
+}
+
+func TestCompleteLine(t *testing.T) {
+	initMap()
+	cfg := newConfig(Options{})
+	cfg.user, _ = actors.NewUser("ash")
+	cfg.user.AddPokemon(actors.OwnedPokemon{Species: "pikachu"})
+	cfg.user.AddPokemon(actors.OwnedPokemon{Species: "pidgey"})
+	cfg.user.AddPokemon(actors.OwnedPokemon{Species: "pikachu"})
+	cfg.lastMapLocations = []string{"canalave-city-area", "eterna-city-area"}
+	cfg.areaPokemon = []string{"tentacool", "tentacruel"}
+	cfg.areaMethods = []string{"old-rod", "surf"}
+	cfg.wild = &encounter.Encounter{Pokemon: "tentacruel", Level: 30}
+
+	cases := []struct {
+		line     string
+		wantHead string
+		want     []string
+	}{
+		{line: "insp", wantHead: "", want: []string{"inspect "}},
+		{line: "explore ", wantHead: "explore ", want: []string{"canalave-city-area ", "eterna-city-area "}},
+		{line: "explore et", wantHead: "explore ", want: []string{"eterna-city-area "}},
+		{line: "catch tentacr", wantHead: "catch ", want: []string{"tentacruel "}},
+		{line: "catch tentacoo", wantHead: "catch ", want: []string{}}, //only the wild pokemon can be caught
+		{line: "encounter ", wantHead: "encounter ", want: []string{"old-rod ", "surf "}},
+		{line: "inspect pi", wantHead: "inspect ", want: []string{"pidgey ", "pikachu "}},
+		{line: "inspect pikachu ", wantHead: "inspect pikachu ", want: []string{}},
+		{line: "profile sw", wantHead: "profile ", want: []string{"switch "}},
+		{line: "pokedex ", wantHead: "pokedex ", want: []string{}},
+		{line: "explore pokémon-ex", wantHead: "explore ", want: []string{}},
+	}
+
+	for _, c := range cases {
+		head, completions, tail := completeLine(cfg, c.line, utf8.RuneCountInString(c.line))
+		if head != c.wantHead || tail != "" {
+			t.Errorf("completing %q, expected head %q, received %q (tail %q)", c.line, c.wantHead, head, tail)
+		}
+		if strings.Join(completions, "|") != strings.Join(c.want, "|") {
+			t.Errorf("completing %q, expected %q, received %q", c.line, c.want, completions)
+		}
+	}
+
+	head, _, tail := completeLine(cfg, "explore é ok", 9) //liner counts the cursor in runes, é is two bytes
+	if head != "explore " || tail != " ok" {
+		t.Errorf("expected the line split after é, received head %q and tail %q", head, tail)
+	}
+}
+
+func TestRunCommandExitCodes(t *testing.T) {
+	scripts := t.TempDir()
+	for name, contents := range map[string]string{"fails-then-exits.txt": "inspect pikachu\nexit\n", "exits.txt": "help\nexit\n"} {
+		if err := os.WriteFile(filepath.Join(scripts, name), []byte(contents), 0o644); err != nil {
+			t.Fatalf("unexpected error writing script: %v", err)
+		}
+	}
+
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{args: []string{"help"}, want: ExitOK},
+		{args: []string{"help", "inspect"}, want: ExitOK},
+		{args: []string{"not-a-command"}, want: ExitUsage},
+		{args: []string{"inspect"}, want: ExitUsage},
+		{args: []string{"inspect", "pikachu", "--shiny"}, want: ExitUsage},
+		{args: []string{"pokedex"}, want: ExitOK}, //nothing caught yet is an empty pokedex, not a failure
+		{args: []string{"pokedex", "-o", "json"}, want: ExitOK},
+		{args: []string{"inspect", "pikachu"}, want: ExitFailed},
+		{args: []string{"exit"}, want: ExitOK},
+		{args: []string{"run", filepath.Join(scripts, "fails-then-exits.txt")}, want: ExitFailed}, //exit doesn't hide the failure before it
+		{args: []string{"run", filepath.Join(scripts, "exits.txt")}, want: ExitOK},
+	}
+
+	for _, c := range cases {
+		opts := Options{Profiles: actors.NewProfileStore(t.TempDir()), Stdout: io.Discard, Stderr: io.Discard}
+		if got := RunCommand(opts, c.args); got != c.want {
+			t.Errorf("running %q, expected exit code %d, received %d", c.args, c.want, got)
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	writeScript := func(t *testing.T, contents string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "script.txt")
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatalf("unexpected error writing script: %v", err)
+		}
+		return path
+	}
+
+	cases := []struct {
+		name       string
+		script     string
+		opts       scriptOptions
+		wantFailed int
+		wantErr    string //empty means the script should run to the end
+	}{
+		{
+			name:   "comments and blank lines are skipped",
+			script: "# a tutorial\n\nhelp\n   # indented comment\nhelp inspect\n",
+		},
+		{
+			name:       "failures are counted and the script keeps going",
+			script:     "inspect pikachu\nnot-a-command\nhelp\ninspect\n",
+			wantFailed: 3,
+		},
+		{
+			name:       "stop on error",
+			script:     "help\ninspect pikachu\nnot-a-command\n",
+			opts:       scriptOptions{stopOnError: true},
+			wantFailed: 1,
+			wantErr:    ":2: You have not caught a pikachu",
+		},
+		{
+			name:       "set -e part way through",
+			script:     "inspect pikachu\nset -e\ninspect bulbasaur\nhelp\n",
+			wantFailed: 2,
+			wantErr:    ":3: You have not caught a bulbasaur",
+		},
+		{
+			name:       "set +e turns it back off",
+			script:     "set -e +e\ninspect pikachu\nhelp\n",
+			wantFailed: 1,
+		},
+		{
+			name:       "exit ends the script without counting as a failure",
+			script:     "inspect pikachu\nexit\nhelp\n",
+			wantFailed: 1,
+			wantErr:    "1 command(s) in",
+		},
+		{
+			name:    "exit with nothing failed",
+			script:  "help\nexit\ninspect pikachu\n",
+			wantErr: "exit requested",
+		},
+		{
+			name:       "unknown set option",
+			script:     "set -q\n",
+			wantFailed: 1,
+		},
+		{
+			name:       "quoted args",
+			script:     "help 'inspect'\nhelp \"unterminated\n",
+			wantFailed: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := setup(Options{Profiles: actors.NewProfileStore(t.TempDir()), Stdout: io.Discard})
+			if err != nil {
+				t.Fatalf("unexpected setup error: %v", err)
+			}
+			failed, err := runScript(context.Background(), cfg, writeScript(t, c.script), c.opts)
+			if failed != c.wantFailed {
+				t.Errorf("expected %d failed lines, received %d", c.wantFailed, failed)
+			}
+			switch {
+			case c.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+				t.Errorf("expected error containing %q, received %v", c.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("a script that runs itself", func(t *testing.T) {
+		cfg, err := setup(Options{Stdout: io.Discard})
+		if err != nil {
+			t.Fatalf("unexpected setup error: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "loop.txt")
+		if err := os.WriteFile(path, []byte("run -e "+path+"\n"), 0o644); err != nil {
+			t.Fatalf("unexpected error writing script: %v", err)
+		}
+		_, err = runScript(context.Background(), cfg, path, scriptOptions{stopOnError: true})
+		if err == nil || !strings.Contains(err.Error(), "nested more than") {
+			t.Errorf("expected the nesting limit to stop the script, received %v", err)
+		}
+	})
+}
+
+func TestLoadBadSaves(t *testing.T) {
+	cfg, err := setup(Options{Profiles: actors.NewProfileStore(t.TempDir()), Stdout: io.Discard, Stderr: io.Discard})
+	if err != nil {
+		t.Fatalf("unexpected setup error: %v", err)
+	}
+	notes := filepath.Join(t.TempDir(), "notes.txt")
+	for _, path := range []string{notes, cfg.saveFile} {
+		if err := os.WriteFile(path, []byte("not a save"), 0o644); err != nil {
+			t.Fatalf("unexpected error writing %s: %v", path, err)
+		}
+	}
+
+	if _, err := commandLoad(context.Background(), cfg, notes); err == nil {
+		t.Error("expected loading notes.txt to fail")
+	}
+	if kept, _ := os.ReadFile(notes); string(kept) != "not a save" {
+		t.Error("expected a file passed to load to be left alone")
+	}
+	if backups, _ := filepath.Glob(notes + ".bak-*"); len(backups) != 0 {
+		t.Errorf("expected no backup of a file passed to load, got %v", backups)
+	}
+
+	if _, err := commandLoad(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "copied to") {
+		t.Errorf("expected the bad autosave to be copied aside, got %v", err)
+	}
+	if backups, _ := filepath.Glob(cfg.saveFile + ".bak-*"); len(backups) != 1 {
+		t.Errorf("expected one backup of the autosave, got %v", backups)
+	}
+	if _, err := os.Stat(cfg.saveFile); err != nil {
+		t.Errorf("expected the autosave to stay where it was, got %v", err)
+	}
+}
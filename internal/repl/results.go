@@ -0,0 +1,300 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/encounter"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// messageResult is for commands that only report what they did, eg save or profile switch
+type messageResult struct {
+	Message string `json:"message"`
+}
+
+func message(format string, a ...any) messageResult {
+	return messageResult{Message: fmt.Sprintf(format, a...)}
+}
+
+func (r messageResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Message)
+	return err
+}
+
+type commandInfo struct {
+	Name        string     `json:"name"`
+	Usage       string     `json:"usage"`
+	Description string     `json:"description"`
+	Flags       []flagInfo `json:"flags,omitempty"`
+}
+
+type flagInfo struct {
+	Name  string `json:"name"`
+	Short string `json:"short,omitempty"`
+	Usage string `json:"usage"`
+}
+
+func (r commandInfo) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Usage: %s\n%s\n", r.Usage, r.Description)
+	for _, flag := range r.Flags {
+		fmt.Fprintf(w, "  --%s: %s\n", flag.Name, flag.Usage)
+	}
+	return nil
+}
+
+type helpResult struct {
+	Commands []commandInfo `json:"commands"`
+}
+
+func (r helpResult) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, "Available commands:")
+	for _, command := range r.Commands {
+		fmt.Fprintf(w, " - %s: %s\n", command.Usage, command.Description)
+	}
+	_, err := fmt.Fprintf(w, "Every command also takes -o|--output <%s> to pick how its result is shown.\n", outputFormatList())
+	return err
+}
+
+func (r helpResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Commands))
+	for _, command := range r.Commands {
+		rows = append(rows, []string{command.Name, command.Usage, command.Description})
+	}
+	return []string{"command", "usage", "description"}, rows
+}
+
+type locationsResult struct {
+	Page      int      `json:"page"`
+	Locations []string `json:"locations"`
+}
+
+func (r locationsResult) WriteText(w io.Writer) error {
+	for _, location := range r.Locations {
+		fmt.Fprintln(w, location)
+	}
+	return nil
+}
+
+func (r locationsResult) Table() ([]string, [][]string) {
+	return []string{"location"}, column(r.Locations)
+}
+
+type exploreResult struct {
+	Location string   `json:"location"`
+	Pokemon  []string `json:"pokemon"`
+	Methods  []string `json:"methods"` //ways to look for them with encounter, eg walk or surf
+}
+
+func (r exploreResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Exploring %s...\n", r.Location)
+	if len(r.Pokemon) == 0 {
+		_, err := fmt.Fprintf(w, "No Pokemon found in %s.\n", r.Location)
+		return err
+	}
+	fmt.Fprintln(w, "Found Pokemon:")
+	for _, pokemon := range r.Pokemon {
+		fmt.Fprintf(w, " - %s\n", pokemon)
+	}
+	if len(r.Methods) > 0 {
+		fmt.Fprintf(w, "Look for one with encounter <%s>\n", strings.Join(r.Methods, "|"))
+	}
+	return nil
+}
+
+type encounterResult struct {
+	Location string `json:"location"`
+	encounter.Encounter
+}
+
+func (r encounterResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Searching %s by %s in pokemon %s...\n", r.Location, r.Method, r.Version)
+	_, err := fmt.Fprintf(w, "A wild level %d %s appeared!\n", r.Level, r.Pokemon)
+	return err
+}
+
+func (r exploreResult) Table() ([]string, [][]string) {
+	return []string{"pokemon"}, column(r.Pokemon)
+}
+
+type catchResult struct {
+	Pokemon string `json:"pokemon"`
+	Level   int    `json:"level"`
+	Ball    string `json:"ball"`
+	Shakes  int    `json:"shakes"` //0 to 3, how many times the ball wobbled before the pokemon broke free or was caught
+	Caught  bool   `json:"caught"`
+	ID      int    `json:"id,omitempty"` //pokedex number of the caught pokemon, 0 when it escaped
+	Shiny   bool   `json:"shiny,omitempty"`
+}
+
+func (r catchResult) WriteText(w io.Writer) error {
+	article := "a"
+	if r.Ball != "" && strings.ContainsRune("AEIOU", rune(r.Ball[0])) {
+		article = "an" //an Ultra Ball
+	}
+	fmt.Fprintf(w, "Throwing %s %s at %s...\n", article, r.Ball, r.Pokemon)
+	if r.Shakes > 0 {
+		shakes := make([]string, r.Shakes)
+		for i := range shakes {
+			shakes[i] = strconv.Itoa(i+1) + "..."
+		}
+		fmt.Fprintf(w, "The ball shook %s\n", strings.Join(shakes, " "))
+	}
+	if !r.Caught {
+		_, err := fmt.Fprintf(w, "%s escaped!\n", r.Pokemon)
+		return err
+	}
+	fmt.Fprintf(w, "%s was caught!\n", r.Pokemon)
+	if r.Shiny {
+		fmt.Fprintf(w, "It's a shiny %s!\n", r.Pokemon)
+	}
+	_, err := fmt.Fprintf(w, "Added to your pokedex as #%d.\n", r.ID)
+	return err
+}
+
+type seedResult struct {
+	Seed    uint64 `json:"seed"`
+	changed bool
+}
+
+func (r seedResult) WriteText(w io.Writer) error {
+	if r.changed {
+		_, err := fmt.Fprintf(w, "Seed set to %d, random outcomes start over from here.\n", r.Seed)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Seed: %d, start the pokedex with --seed %d and repeat the same commands to get the same outcomes.\n", r.Seed, r.Seed)
+	return err
+}
+
+type statInfo struct {
+	Name     string `json:"name"`
+	BaseStat int    `json:"base_stat"`
+	IV       int    `json:"iv"`
+	EV       int    `json:"ev"`
+}
+
+type inspectResult struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Level     int        `json:"level"`
+	XP        int        `json:"xp"`
+	HP        int        `json:"hp"`
+	MaxHP     int        `json:"max_hp"`
+	Nature    string     `json:"nature"`
+	Gender    string     `json:"gender"`
+	Shiny     bool       `json:"shiny"`
+	Abilities []string   `json:"abilities"`
+	Moves     []string   `json:"moves"`
+	Types     []string   `json:"types,omitempty"` //only filled in for a full inspection
+	Stats     []statInfo `json:"stats,omitempty"`
+	CaughtAt  string     `json:"caught_at,omitempty"`
+	CaughtOn  *time.Time `json:"caught_on,omitempty"`
+	full      bool
+}
+
+func (r inspectResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Name: %s (#%d)\n", r.Name, r.ID)
+	fmt.Fprintf(w, "Level: %d, %d XP\n", r.Level, r.XP)
+	fmt.Fprintf(w, "HP: %d/%d\n", r.HP, r.MaxHP)
+	fmt.Fprintf(w, "Nature: %s\n", r.Nature)
+	fmt.Fprintf(w, "Gender: %s\n", r.Gender)
+	if r.Shiny {
+		fmt.Fprintln(w, "Shiny!")
+	}
+	writeList(w, "Abilities", r.Abilities)
+	writeList(w, "Moves", r.Moves)
+	if !r.full {
+		return nil
+	}
+	writeList(w, "Types", r.Types)
+	fmt.Fprintln(w, "Stats:")
+	for _, stat := range r.Stats {
+		fmt.Fprintf(w, " - %s: %d (IV %d, EV %d)\n", stat.Name, stat.BaseStat, stat.IV, stat.EV)
+	}
+	caught := "Caught"
+	if r.CaughtAt != "" {
+		caught += " in " + r.CaughtAt
+	}
+	if r.CaughtOn != nil {
+		caught += " on " + r.CaughtOn.Format(time.DateOnly)
+	}
+	if caught != "Caught" {
+		fmt.Fprintln(w, caught)
+	}
+	return nil
+}
+
+type pokedexEntry struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Level int      `json:"level"`
+	Types []string `json:"types"`
+}
+
+type pokedexResult struct {
+	Pokemon []pokedexEntry `json:"pokemon"`
+}
+
+func (r pokedexResult) WriteText(w io.Writer) error {
+	if len(r.Pokemon) == 0 {
+		_, err := fmt.Fprintln(w, "You have not caught any pokemon")
+		return err
+	}
+	fmt.Fprintln(w, "Your pokedex:")
+	for _, entry := range r.Pokemon {
+		fmt.Fprintf(w, " - #%d %s, level %d\n", entry.ID, entry.Name, entry.Level)
+	}
+	return nil
+}
+
+func (r pokedexResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Pokemon))
+	for _, entry := range r.Pokemon {
+		rows = append(rows, []string{strconv.Itoa(entry.ID), entry.Name, strconv.Itoa(entry.Level), strings.Join(entry.Types, ",")})
+	}
+	return []string{"id", "name", "level", "types"}, rows
+}
+
+type profilesResult struct {
+	Active   string   `json:"active"`
+	Profiles []string `json:"profiles"`
+}
+
+func (r profilesResult) WriteText(w io.Writer) error {
+	if len(r.Profiles) == 0 {
+		_, err := fmt.Fprintf(w, "No saved profiles yet, %s will be saved on exit.\n", r.Active)
+		return err
+	}
+	for _, name := range r.Profiles {
+		marker := " "
+		if name == r.Active {
+			marker = "*"
+		}
+		fmt.Fprintf(w, " %s %s\n", marker, name)
+	}
+	return nil
+}
+
+func (r profilesResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Profiles))
+	for _, name := range r.Profiles {
+		rows = append(rows, []string{name, strconv.FormatBool(name == r.Active)})
+	}
+	return []string{"profile", "active"}, rows
+}
+
+func writeList(w io.Writer, title string, items []string) {
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, " - %s\n", item)
+	}
+}
+
+func column(values []string) [][]string {
+	rows := make([][]string, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, []string{value})
+	}
+	return rows
+}
@@ -0,0 +1,97 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/encounter"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"os"
+)
+
+func commandSave(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	path := cfg.saveFile
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		return nil, fmt.Errorf("No save file configured. Usage: save <file>")
+	}
+	if err := saveGame(cfg, path); err != nil {
+		return nil, fmt.Errorf("Error saving game: %w", err)
+	}
+	return message("Saved %d caught pokemon to %s", len(cfg.user.CaughtPokemon), path), nil
+}
+
+func commandLoad(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	path := cfg.saveFile
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		return nil, fmt.Errorf("No save file configured. Usage: load <file>")
+	}
+	err := loadGame(cfg, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("There is no save at %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error loading game: %w", err)
+	}
+	return message("Loaded %d caught pokemon from %s", len(cfg.user.CaughtPokemon), path), nil
+}
+
+func saveGame(cfg *config, path string) error {
+	if path == "" {
+		return nil
+	}
+	var wild *actors.WildPokemon
+	if cfg.wild != nil {
+		wild = &actors.WildPokemon{Pokemon: cfg.wild.Pokemon, Level: cfg.wild.Level, Method: cfg.wild.Method, Version: cfg.wild.Version}
+	}
+	return actors.SaveGame(path, actors.SaveFile{
+		CaughtPokemon: cfg.user.CaughtPokemon,
+		Location: actors.LocationState{
+			Current:      cfg.currentLocation,
+			NextPage:     cfg.nextLocationPage,
+			PreviousPage: cfg.previousLocationPage,
+			Wild:         wild,
+		},
+		GameVersion: cfg.version,
+	})
+}
+
+// loadGame only touches cfg once the save has been read successfully, a bad file leaves the current game alone.
+// A bad autosave is copied aside first since the save on exit replaces it, any other file is the user's to deal with.
+func loadGame(cfg *config, path string) error {
+	if path == "" {
+		return nil
+	}
+	save, err := actors.LoadGame(path)
+	if (errors.Is(err, actors.ErrCorruptSave) || errors.Is(err, actors.ErrFutureSave)) && path == cfg.saveFile {
+		backup, backupErr := actors.BackupSave(path)
+		if backupErr != nil {
+			return fmt.Errorf("%w, and it could not be backed up: %v", err, backupErr)
+		}
+		return fmt.Errorf("%w, it was copied to %s", err, backup)
+	}
+	if err != nil {
+		return err
+	}
+
+	resetSession(cfg)
+	cfg.user.CaughtPokemon = save.CaughtPokemon
+	cfg.nextLocationPage = save.Location.NextPage
+	cfg.previousLocationPage = save.Location.PreviousPage
+	cfg.currentLocation = save.Location.Current
+	cfg.version = save.GameVersion
+	cfg.currentLocationURL = ""
+	if cfg.currentLocation != "" {
+		cfg.currentLocationURL = cfg.client.LocationAreaURL(cfg.currentLocation)
+	}
+	if wild := save.Location.Wild; wild != nil && cfg.currentLocation != "" {
+		cfg.wild = &encounter.Encounter{Pokemon: wild.Pokemon, Level: wild.Level, Method: wild.Method, Version: wild.Version}
+	}
+	return nil
+}
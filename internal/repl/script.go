@@ -0,0 +1,143 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"os"
+	"strings"
+)
+
+const maxScriptDepth = 8 //scripts may run other scripts, but a script running itself should not hang the pokedex
+
+type scriptOptions struct {
+	stopOnError bool //like `set -e`, the first failing line ends the script
+	echo        bool //like `set -x`, print each line with the prompt before running it
+}
+
+// scriptExitError is a script reaching exit after some of its lines failed. It still means exit, but one shot mode
+// reports the failures in its exit code instead of treating the script as a success.
+type scriptExitError struct {
+	failed int
+	path   string
+}
+
+func (e *scriptExitError) Error() string {
+	return fmt.Sprintf("%d command(s) in %s failed", e.failed, e.path)
+}
+
+func (e *scriptExitError) Unwrap() error {
+	return errExit
+}
+
+func commandRun(ctx context.Context, cfg *config, args ...string) (render.Result, error) { //each line renders its own result, run has none
+	opts := scriptOptions{stopOnError: cfg.hasFlag("stop-on-error"), echo: cfg.hasFlag("echo")}
+	failed, err := runScript(ctx, cfg, args[0], opts)
+	if err != nil {
+		return nil, err
+	}
+	if failed > 0 {
+		return nil, fmt.Errorf("%d command(s) in %s failed", failed, args[0])
+	}
+	return nil, nil
+}
+
+// runScript runs every line of the file at path as if it was typed at the prompt, returning how many lines failed.
+// Blank lines and lines starting with # are skipped, `set -e`/`set +e` and `set -x`/`set +x` toggle opts part way through.
+// A non nil error means the script stopped early: it could not be read, was cancelled, or a line failed with stopOnError set.
+func runScript(ctx context.Context, cfg *config, path string, opts scriptOptions) (int, error) {
+	if cfg.scriptDepth >= maxScriptDepth {
+		return 0, fmt.Errorf("Scripts are nested more than %d deep, does %s run itself?", maxScriptDepth, path)
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("There is no script at %s", path)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Error opening script: %w", err)
+	}
+	defer file.Close()
+
+	cfg.scriptDepth++
+	defer func() { cfg.scriptDepth-- }()
+
+	failed := 0
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if ctx.Err() != nil {
+			return failed, fmt.Errorf("Script cancelled at %s:%d", path, lineNumber)
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if opts.echo {
+			fmt.Fprintf(cfg.out, "%s %s\n", prompt(cfg), line)
+		}
+
+		err := runScriptLine(ctx, cfg, line, &opts)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, errExit) { //exit leaves the pokedex, not just the script
+			var nested *scriptExitError
+			if errors.As(err, &nested) {
+				failed += nested.failed //a script run by this one exited after its own failures
+			}
+			if failed > 0 {
+				return failed, &scriptExitError{failed: failed, path: path}
+			}
+			return failed, err
+		}
+		failed++
+		if opts.stopOnError {
+			return failed, fmt.Errorf("%s:%d: %v", path, lineNumber, err) //not wrapped, a bad line in a script is not a usage error for run itself
+		}
+		fmt.Fprintf(cfg.out, "%s:%d: %v\n", path, lineNumber, err)
+	}
+	if err := scanner.Err(); err != nil {
+		return failed, fmt.Errorf("Error reading script: %w", err)
+	}
+	return failed, nil
+}
+
+// runScriptLine runs one command under the script's context, so Ctrl-C stops the whole script rather than just the current line
+func runScriptLine(ctx context.Context, cfg *config, line string, opts *scriptOptions) error {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(tokens[0]) == "set" {
+		return applyScriptOption(tokens[1:], opts)
+	}
+
+	commandName := strings.ToLower(tokens[0])
+	command, exists := commandDictionary[commandName]
+	if !exists {
+		return fmt.Errorf("Unknown command: %s", commandName)
+	}
+	return runCommandContext(ctx, cfg, command, tokens[1:])
+}
+
+func applyScriptOption(options []string, opts *scriptOptions) error {
+	if len(options) == 0 {
+		return fmt.Errorf("Usage: set <-e|+e|-x|+x>")
+	}
+	for _, option := range options {
+		switch option {
+		case "-e":
+			opts.stopOnError = true
+		case "+e":
+			opts.stopOnError = false
+		case "-x":
+			opts.echo = true
+		case "+x":
+			opts.echo = false
+		default:
+			return fmt.Errorf("Unknown script option %s, expected -e, +e, -x or +x", option)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+type shutdownHook struct {
+	name string
+	run  func() error
+}
+
+// interruptHandler decides what Ctrl-C means: cancel the running command, or exit if there is nothing left to cancel
+type interruptHandler struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc //cancels the running command, nil at the prompt
+	armed   bool               //the last Ctrl-C had nothing to cancel, so the next one exits
+	running sync.Mutex         //held while a command runs, so a signal that closes the pokedex waits for it instead of racing it
+}
+
+// commandContext hands out the context for one command, done must be called once the command returns
+func (h *interruptHandler) commandContext() (context.Context, func()) {
+	h.running.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	h.mu.Lock()
+	h.cancel = cancel
+	h.armed = false //running a command means the trainer didn't want to leave after all
+	h.mu.Unlock()
+
+	return ctx, func() {
+		h.mu.Lock()
+		h.cancel = nil
+		h.mu.Unlock()
+		cancel()
+		h.running.Unlock()
+	}
+}
+
+// stop cancels the running command and waits for it to return, no command runs after it so the shutdown hooks have the config to themselves
+func (h *interruptHandler) stop() {
+	h.mu.Lock()
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+	h.mu.Unlock()
+	h.running.Lock() //never unlocked, the process exits once the hooks are done
+}
+
+type interruptAction int
+
+const (
+	interruptCancelled interruptAction = iota //stopped the running command
+	interruptArmed                            //nothing to cancel, warned that another Ctrl-C exits
+	interruptExit
+)
+
+// interrupt handles one Ctrl-C
+func (h *interruptHandler) interrupt() interruptAction {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+		h.armed = true
+		return interruptCancelled
+	}
+	if h.armed {
+		return interruptExit
+	}
+	h.armed = true
+	return interruptArmed
+}
+
+// onShutdown registers a cleanup step, hooks run in the order they were added
+func (cfg *config) onShutdown(name string, run func() error) {
+	cfg.shutdownHooks = append(cfg.shutdownHooks, shutdownHook{name: name, run: run})
+}
+
+// shutdown runs every hook once no matter how many paths (exit, EOF, signal) try to close the pokedex
+func (cfg *config) shutdown() {
+	cfg.shutdownOnce.Do(func() {
+		for _, hook := range cfg.shutdownHooks {
+			if err := hook.run(); err != nil {
+				fmt.Fprintf(cfg.errOut, "Error during %s shutdown: %v\n", hook.name, err) //keep going so one bad hook doesn't skip the rest
+			}
+		}
+	})
+}
+
+func handleSignals(cfg *config) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGTERM {
+				fmt.Fprintf(cfg.out, "\nReceived %v, closing the Pokedex...\n", sig)
+				cfg.interrupts.stop()
+				cfg.shutdown()
+				os.Exit(143)
+			}
+
+			switch cfg.interrupts.interrupt() {
+			case interruptCancelled:
+				fmt.Fprintln(cfg.out) //the command prints its own cancelled message and the prompt comes back after it
+			case interruptArmed:
+				fmt.Fprintf(cfg.out, "\n(press Ctrl-C again or Ctrl-D to exit)\n%s", prompt(cfg))
+			case interruptExit:
+				fmt.Fprintln(cfg.out)
+				cfg.interrupts.stop()
+				cfg.render(goodbye)
+				cfg.shutdown()
+				os.Exit(0)
+			}
+		}
+	}()
+}
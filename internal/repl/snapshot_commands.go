@@ -0,0 +1,170 @@
+package repl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"io"
+	"strings"
+)
+
+const snapshotUsage = "Usage: snapshot <region|area|pokemon|version> <name>"
+
+func commandSnapshot(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if cfg.offline {
+		return nil, fmt.Errorf("Snapshots are downloaded from PokeAPI, run the pokedex without --offline to take one.")
+	}
+	if cfg.snapshot == nil {
+		return nil, fmt.Errorf("No snapshot directory configured, set snapshot-dir to take snapshots.")
+	}
+
+	d := &snapshotDownload{ctx: ctx, cfg: cfg, seen: make(map[string]bool), result: snapshotResult{Dir: cfg.snapshot.Dir()}}
+	var err error
+	switch args[0] {
+	case "region":
+		err = d.region(args[1])
+	case "area":
+		err = d.area(args[1])
+	case "pokemon":
+		err = d.pokemon(args[1])
+	case "version":
+		err = d.version(args[1])
+	default:
+		return nil, fmt.Errorf("Unknown snapshot subcommand: %s\n%s", args[0], snapshotUsage)
+	}
+	if err != nil {
+		kind, name, _ := strings.Cut(d.current, "/") //a region can fail on any of its areas or pokemon, name the one that did
+		return nil, describeAPIError(err, fmt.Sprintf("PokeAPI has no %s named %s.", kind, name))
+	}
+	return d.result, nil
+}
+
+// snapshotDownload walks from a region down to its pokemon, saving each response on the way. Anything already saved
+// this run is skipped, most pokemon turn up in several areas.
+type snapshotDownload struct {
+	ctx     context.Context
+	cfg     *config
+	seen    map[string]bool
+	current string //resource being downloaded, so errors can say which one failed
+	result  snapshotResult
+}
+
+func (d *snapshotDownload) region(name string) error {
+	var region pokeapi.Region
+	if saved, err := d.save("region/"+name, &region); err != nil || !saved {
+		return err
+	}
+	d.result.Regions++
+	for _, location := range region.Locations {
+		if err := d.location(location.Name); err != nil {
+			return err
+		}
+	}
+	for _, group := range region.VersionGroups { //so version can pick one of the region's games offline
+		if err := d.versionGroup(group.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *snapshotDownload) location(name string) error {
+	var location pokeapi.Location
+	if saved, err := d.save("location/"+name, &location); err != nil || !saved {
+		return err
+	}
+	d.result.Locations++
+	for _, area := range location.Areas {
+		if err := d.area(area.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *snapshotDownload) area(name string) error {
+	var area pokeapi.LocationArea
+	if saved, err := d.save("location-area/"+name, &area); err != nil || !saved {
+		return err
+	}
+	d.result.Areas++
+	for _, encounter := range area.PokemonEncounters {
+		if err := d.pokemon(encounter.Pokemon.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *snapshotDownload) pokemon(name string) error {
+	var pokemon struct {
+		Species pokeapi.NamedResource `json:"species"`
+	}
+	if saved, err := d.save("pokemon/"+name, &pokemon); err != nil || !saved {
+		return err
+	}
+	d.result.Pokemon++
+	species := pokemon.Species.Name
+	if species == "" {
+		species = name
+	}
+	_, err := d.save("pokemon-species/"+species, &struct{}{}) //catch needs the capture rate
+	return err
+}
+
+func (d *snapshotDownload) version(name string) error {
+	var version pokeapi.Version
+	if saved, err := d.save("version/"+name, &version); err != nil || !saved {
+		return err
+	}
+	d.result.Versions++
+	return d.versionGroup(version.VersionGroup.Name)
+}
+
+// versionGroup saves the group and every version in it, its regions are left out since each is a download of its own
+func (d *snapshotDownload) versionGroup(name string) error {
+	var group pokeapi.VersionGroup
+	if saved, err := d.save("version-group/"+name, &group); err != nil || !saved {
+		return err
+	}
+	for _, version := range group.Versions {
+		if err := d.version(version.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// save downloads resource into the snapshot and decodes it into target, reporting false when it was already saved this run
+func (d *snapshotDownload) save(resource string, target any) (bool, error) {
+	if d.seen[resource] {
+		return false, nil
+	}
+	d.seen[resource] = true
+	d.current = resource
+	body, err := d.cfg.client.Download(d.ctx, d.cfg.snapshot, resource)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(d.cfg.errOut, "Saved %s\n", resource) //progress, kept out of out so json output stays parseable
+	if err := json.Unmarshal(body, target); err != nil {
+		return false, fmt.Errorf("Could not read %s: %w", resource, err)
+	}
+	return true, nil
+}
+
+type snapshotResult struct {
+	Dir       string `json:"dir"`
+	Regions   int    `json:"regions"`
+	Locations int    `json:"locations"`
+	Areas     int    `json:"areas"`
+	Pokemon   int    `json:"pokemon"`
+	Versions  int    `json:"versions"`
+}
+
+func (r snapshotResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Saved %d region(s), %d location(s), %d area(s), %d pokemon and %d version(s) to %s\n", r.Regions, r.Locations, r.Areas, r.Pokemon, r.Versions, r.Dir)
+	return err
+}
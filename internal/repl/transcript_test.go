@@ -0,0 +1,170 @@
+package repl
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"github.com/CSelvidge/pokedexcli/internal/pokecache"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden transcripts in testdata/transcripts with the current output")
+
+// fakePokeAPI serves testdata/pokeapi laid out like the real API, eg /pokemon/chansey is pokemon/chansey.json.
+// Location pages are location-area/index-<offset>.json since the page is in the query string.
+func fakePokeAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file := strings.TrimSuffix(r.URL.Path, "/") + ".json"
+		if r.URL.Path == "/location-area" {
+			file = fmt.Sprintf("/location-area/index-%s.json", r.URL.Query().Get("offset"))
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "pokeapi", filepath.FromSlash(file)))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// transcriptReader feeds a session its input and echoes each line after the prompt, so the output reads like a terminal
+type transcriptReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *transcriptReader) readLine(prompt string) (string, error) {
+	if !r.scanner.Scan() {
+		return "", io.EOF
+	}
+	fmt.Fprintf(r.out, "%s %s\n", prompt, r.scanner.Text())
+	return r.scanner.Text(), nil
+}
+
+func (r *transcriptReader) close() error {
+	return nil
+}
+
+// transcriptSession is where a transcript runs, an empty snapshotDir gets a fresh temp dir
+type transcriptSession struct {
+	serverURL   string            //empty uses the public API, which is only safe with a transport that replays fixtures
+	transport   http.RoundTripper //nil uses the default transport
+	snapshotDir string
+	offline     bool //read from snapshotDir instead of the fake API
+}
+
+// runTranscript plays input through a whole session and returns everything it printed.
+// The profile and snapshot directories change every run, so they show up as <profiles> and <snapshot> in the output.
+func runTranscript(t *testing.T, session transcriptSession, input io.Reader) string {
+	t.Helper()
+	cache, err := pokecache.NewCache("minute", 5)
+	if err != nil {
+		t.Fatalf("unexpected error creating cache: %v", err)
+	}
+	if session.snapshotDir == "" {
+		session.snapshotDir = t.TempDir()
+	}
+	snapshot := pokeapi.NewSnapshot(session.snapshotDir)
+	clientConfig := pokeapi.ClientConfig{BaseURL: session.serverURL, HTTPClient: &http.Client{Transport: session.transport}, Cache: cache}
+	if session.offline {
+		clientConfig.Source = snapshot
+	}
+	profileDir := t.TempDir()
+	var out strings.Builder
+	cfg, err := setup(Options{
+		Client:   pokeapi.NewClient(clientConfig),
+		Cache:    cache,
+		Profiles: actors.NewProfileStore(profileDir),
+		Stdout:   &out,
+		Stderr:   &out,
+		Snapshot: snapshot,
+		Offline:  session.offline,
+		Seed:     1, //fixed so catches come out the same every run
+		Now:      func() time.Time { return time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected setup error: %v", err)
+	}
+	cfg.input = &transcriptReader{scanner: bufio.NewScanner(input), out: &out}
+	getUserInput(cfg)
+	cfg.shutdown()
+	transcript := strings.ReplaceAll(out.String(), profileDir, "<profiles>")
+	return strings.ReplaceAll(transcript, session.snapshotDir, "<snapshot>")
+}
+
+// TestTranscripts runs every testdata/transcripts/<name>.txt and diffs the output against <name>.golden.
+// Transcripts named offline-*.txt run offline against a snapshot of the fake API's sinnoh region taken first.
+// Run `go test ./internal/repl -run TestTranscripts -update` after an intended output change and review the diff.
+func TestTranscripts(t *testing.T) {
+	server := fakePokeAPI(t)
+	inputs, err := filepath.Glob(filepath.Join("testdata", "transcripts", "*.txt"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no transcripts found: %v", err)
+	}
+
+	for _, inputPath := range inputs {
+		name := strings.TrimSuffix(filepath.Base(inputPath), ".txt")
+		t.Run(name, func(t *testing.T) {
+			session := transcriptSession{serverURL: server.URL}
+			if strings.HasPrefix(name, "offline-") {
+				session = transcriptSession{snapshotDir: t.TempDir(), offline: true}
+				runTranscript(t, transcriptSession{serverURL: server.URL, snapshotDir: session.snapshotDir}, strings.NewReader("snapshot region sinnoh\n"))
+			}
+			input, err := os.Open(inputPath)
+			if err != nil {
+				t.Fatalf("unexpected error opening transcript: %v", err)
+			}
+			defer input.Close()
+			got := runTranscript(t, session, input)
+			checkGolden(t, strings.TrimSuffix(inputPath, ".txt")+".golden", got)
+		})
+	}
+}
+
+func checkGolden(t *testing.T, goldenPath, got string) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+			t.Fatalf("unexpected error writing golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("missing golden file, run with -update to create it: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s\n%s", goldenPath, diffLines(string(want), got))
+	}
+}
+
+// diffLines is just enough of a diff to find where a transcript went wrong, every line after the first difference is shown
+func diffLines(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	first := 0
+	for first < len(wantLines) && first < len(gotLines) && wantLines[first] == gotLines[first] {
+		first++
+	}
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "first difference at line %d\n", first+1)
+	for _, line := range wantLines[first:] {
+		fmt.Fprintf(&diff, "- %s\n", line)
+	}
+	for _, line := range gotLines[first:] {
+		fmt.Fprintf(&diff, "+ %s\n", line)
+	}
+	return diff.String()
+}
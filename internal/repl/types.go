@@ -1,84 +1,56 @@
 package repl
 
 import (
-	"github.com/CSelvidge/pokedexcli/internal/pokecache"
+	"context"
 	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/encounter"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
+	"github.com/CSelvidge/pokedexcli/internal/pokecache"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
 )
 
 type cliCommand struct {
 	name        string
 	description string
-	callback    func(*config, ...string) error //allows for variadic functions, if more arguments are needed in the future change to slice of strings
+	args        []argSpec                                                        //positional args, checked by parseArgs before the callback runs
+	flags       []flagSpec                                                       //options like --full, the parsed values end up in config.flags
+	complete    func(cfg *config, prior []string) []string                       //tab completion candidates for the next arg, given the args before it
+	callback    func(context.Context, *config, ...string) (render.Result, error) //ctx is cancelled by Ctrl-C while the command runs, the result is rendered in the chosen output format, nil means nothing to show
 }
 
 type config struct {
-	nextLocationsURL     string
-	previousLocationsURL string
-	currentLocation	 string
-	currentLocationURL  string
+	nextLocationPage     int //page map shows next, wraps to 0 after the last page
+	previousLocationPage int //page mapb shows, -1 until map has gone past the first page
+	currentLocation      string
+	currentLocationURL   string
+	client               *pokeapi.Client
 	cache                *pokecache.Cache
-	user				 *actors.User
-}
-
-type locationResponse struct {
-	Count    int    `json:"count"`
-	Next     string `json:"next"`
-	Previous string `json:"previous"`
-	Results  []struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"results"`
-}
-
-type exploreResponse struct {
-	EncounterMethodRates []struct {
-		EncounterMethod struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"encounter_method"`
-		VersionDetails []struct {
-			Rate    int `json:"rate"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"encounter_method_rates"`
-	GameIndex int `json:"game_index"`
-	ID        int `json:"id"`
-	Location  struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"location"`
-	Name  string `json:"name"`
-	Names []struct {
-		Language struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"language"`
-		Name string `json:"name"`
-	} `json:"names"`
-	PokemonEncounters []struct {
-		Pokemon struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"pokemon"`
-		VersionDetails []struct {
-			EncounterDetails []struct {
-				Chance          int   `json:"chance"`
-				ConditionValues []any `json:"condition_values"`
-				MaxLevel        int   `json:"max_level"`
-				Method          struct {
-					Name string `json:"name"`
-					URL  string `json:"url"`
-				} `json:"method"`
-				MinLevel int `json:"min_level"`
-			} `json:"encounter_details"`
-			MaxChance int `json:"max_chance"`
-			Version   struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"pokemon_encounters"`
+	cacheFile            string               //where the cache snapshot lives, empty disables persistence
+	saveFile             string               //active profile's save, used by save, load and the save on exit, empty disables autosave
+	profiles             *actors.ProfileStore //nil when there is nowhere to keep profiles
+	user                 *actors.User
+	shutdownHooks        []shutdownHook
+	shutdownOnce         *sync.Once
+	interrupts           *interruptHandler
+	flags                map[string]string //options passed to the running command, replaced on every command
+	output               render.Format     //how command results are shown, -o on a single command overrides it
+	snapshot             *pokeapi.Snapshot //where snapshot saves to, nil when there is no snapshot directory
+	offline              bool
+	input                lineReader
+	out                  io.Writer            //everything the session shows goes here, commands never touch os.Stdout
+	errOut               io.Writer            //errors from one shot commands and warnings like a save that would not load, the REPL keeps command errors in out
+	lastMapLocations     []string             //location names from the last map or mapb page, for completion
+	areaPokemon          []string             //pokemon in the last explored area, for completion
+	areaMethods          []string             //encounter methods in the last explored area, for completion
+	wild                 *encounter.Encounter //the pokemon encounter turned up, the only one catch can target, nil when nothing is out
+	version              *actors.GameVersion  //game being played, nil shows every game's data
+	versionLocations     []string             //locations in the version's regions, loaded by the first map after picking a version
+	scriptDepth          int                  //how many run commands are in progress, guards against scripts that run themselves
+	seed                 uint64               //what rng was last seeded with, shown by the seed command so a session can be replayed
+	rng                  *rand.Rand           //every random outcome in the game comes from here, never the global source
+	now                  func() time.Time     //when a pokemon is caught, swapped out by tests
 }
@@ -0,0 +1,83 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"io"
+	"strings"
+)
+
+func commandVersion(ctx context.Context, cfg *config, args ...string) (render.Result, error) {
+	if len(args) == 0 {
+		return newVersionResult(cfg.version, false), nil
+	}
+	if args[0] == "all" {
+		setVersion(cfg, nil)
+		return newVersionResult(nil, true), nil
+	}
+
+	version, err := cfg.client.GetVersion(ctx, args[0])
+	if err != nil {
+		return nil, describeAPIError(err, fmt.Sprintf("There is no pokemon version named %s, eg diamond, heartgold or black-2.", args[0]))
+	}
+	group, err := cfg.client.GetVersionGroup(ctx, version.VersionGroup.Name)
+	if err != nil {
+		return nil, describeAPIError(err, fmt.Sprintf("PokeAPI has no version group named %s.", version.VersionGroup.Name))
+	}
+	picked := &actors.GameVersion{Name: version.Name, VersionGroup: group.Name, Regions: []string{}}
+	for _, region := range group.Regions {
+		picked.Regions = append(picked.Regions, region.Name)
+	}
+	setVersion(cfg, picked)
+	return newVersionResult(picked, true), nil
+}
+
+// setVersion switches the game being played. Map pages through a different list afterwards, so it starts over,
+// and a wild pokemon from the old game's encounter table runs off.
+func setVersion(cfg *config, version *actors.GameVersion) {
+	cfg.version = version
+	cfg.versionLocations = nil
+	cfg.nextLocationPage = 0
+	cfg.previousLocationPage = -1
+	cfg.lastMapLocations = nil
+	cfg.wild = nil
+}
+
+// versionName is the game being played, empty when every game's data is shown
+func (cfg *config) versionName() string {
+	if cfg.version == nil {
+		return ""
+	}
+	return cfg.version.Name
+}
+
+type versionResult struct {
+	Name         string   `json:"name"` //empty when every game's data is shown
+	VersionGroup string   `json:"version_group"`
+	Regions      []string `json:"regions"`
+	changed      bool
+}
+
+func newVersionResult(version *actors.GameVersion, changed bool) versionResult {
+	if version == nil {
+		return versionResult{Regions: []string{}, changed: changed}
+	}
+	return versionResult{Name: version.Name, VersionGroup: version.VersionGroup, Regions: version.Regions, changed: changed}
+}
+
+func (r versionResult) WriteText(w io.Writer) error {
+	var err error
+	switch {
+	case r.Name == "" && r.changed:
+		_, err = fmt.Fprintln(w, "Showing every game's data again.")
+	case r.Name == "":
+		_, err = fmt.Fprintln(w, "Showing every game's data, use version <name> to pick one, eg version diamond.")
+	case r.changed:
+		_, err = fmt.Fprintf(w, "Now playing pokemon %s (%s, %s). Map, explore, encounter and inspect only show its data.\n", r.Name, r.VersionGroup, strings.Join(r.Regions, ", "))
+	default:
+		_, err = fmt.Fprintf(w, "Playing pokemon %s (%s, %s).\n", r.Name, r.VersionGroup, strings.Join(r.Regions, ", "))
+	}
+	return err
+}
@@ -0,0 +1,402 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/render"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "POKEDEX_"
+
+// Settings is everything the pokedex can be configured with before the REPL starts.
+// Sources are layered, later ones win: defaults, config file, POKEDEX_* env vars, then command line flags.
+type Settings struct {
+	CacheUnit       string //second, minute or hour
+	CacheLife       int    //number of CacheUnits an entry lives for
+	CacheFile       string //empty uses the default location under the user cache dir
+	ProfileDir      string //where trainer saves live, empty uses the default location under the user config dir
+	Profile         string //profile to start with, empty uses the one active last time
+	HistoryFile     string //line editor history, empty uses the default location under the user config dir
+	CacheMaxEntries int
+	CacheMaxBytes   int
+	HTTPTimeout     time.Duration //per request, including reading the body
+	HTTPRetries     int           //extra attempts after the first for network errors, 429s and 5xxs
+	APIURL          string        //PokeAPI base URL, empty uses the public API
+	Debug           bool          //log every request to stderr
+	Output          render.Format //how command results are shown
+	Offline         bool          //read PokeAPI data from SnapshotDir instead of the network
+	SnapshotDir     string        //empty uses the default location under the user config dir
+	RecordDir       string        //when set, every PokeAPI request and response is saved here as a test fixture
+	Seed            uint64        //seeds catches and encounters, 0 picks a random seed
+	ConfigFile      string        //the config file that was read, if any
+	Script          string        //file of commands to run instead of starting the REPL
+	Args            []string      //positional args left over after the flags
+}
+
+type setting struct {
+	name    string //flag name, env var is POKEDEX_ + name uppercased with dashes as underscores, config file key is the same as the flag
+	usage   string
+	boolean bool //the flag can be passed without a value, eg --offline means --offline=true
+	apply   func(s *Settings, raw string) error
+}
+
+// flagValue holds the raw text of a flag so it can be applied after the config file and env vars, like every other source
+type flagValue struct {
+	raw     string
+	boolean bool
+}
+
+func (f *flagValue) String() string { return f.raw }
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.boolean }
+
+var knownSettings = []setting{
+	{
+		name:  "cache-unit",
+		usage: "cache lifetime unit: second, minute or hour",
+		apply: func(s *Settings, raw string) error {
+			unit, err := normalizeUnit(raw)
+			if err != nil {
+				return err
+			}
+			s.CacheUnit = unit
+			return nil
+		},
+	},
+	{
+		name:  "cache-life",
+		usage: "cache lifetime in cache-units, must be at least 1",
+		apply: func(s *Settings, raw string) error {
+			return parsePositive(raw, 1, &s.CacheLife)
+		},
+	},
+	{
+		name:  "cache-file",
+		usage: "where to persist the cache between sessions",
+		apply: func(s *Settings, raw string) error {
+			s.CacheFile = raw
+			return nil
+		},
+	},
+	{
+		name:  "profile-dir",
+		usage: "directory holding one save file per trainer profile",
+		apply: func(s *Settings, raw string) error {
+			s.ProfileDir = raw
+			return nil
+		},
+	},
+	{
+		name:  "profile",
+		usage: "trainer profile to start with",
+		apply: func(s *Settings, raw string) error {
+			name := strings.ToLower(strings.TrimSpace(raw))
+			if !actors.ValidProfileName(name) {
+				return fmt.Errorf("invalid profile name %q, use lowercase letters, numbers, dashes and underscores", raw)
+			}
+			s.Profile = name
+			return nil
+		},
+	},
+	{
+		name:  "history-file",
+		usage: "where command history is kept between sessions",
+		apply: func(s *Settings, raw string) error {
+			s.HistoryFile = raw
+			return nil
+		},
+	},
+	{
+		name:  "cache-max-entries",
+		usage: "maximum number of cached responses, 0 for no limit",
+		apply: func(s *Settings, raw string) error {
+			return parsePositive(raw, 0, &s.CacheMaxEntries)
+		},
+	},
+	{
+		name:  "cache-max-bytes",
+		usage: "maximum total size of cached responses in bytes, 0 for no limit",
+		apply: func(s *Settings, raw string) error {
+			return parsePositive(raw, 0, &s.CacheMaxBytes)
+		},
+	},
+	{
+		name:  "http-timeout",
+		usage: "timeout for each PokeAPI request, eg 10s",
+		apply: func(s *Settings, raw string) error {
+			timeout, err := time.ParseDuration(strings.TrimSpace(raw))
+			if err != nil || timeout <= 0 {
+				return fmt.Errorf("invalid duration %q, expected something like 10s", raw)
+			}
+			s.HTTPTimeout = timeout
+			return nil
+		},
+	},
+	{
+		name:  "http-retries",
+		usage: "how many times to retry a failed PokeAPI request",
+		apply: func(s *Settings, raw string) error {
+			return parsePositive(raw, 0, &s.HTTPRetries)
+		},
+	},
+	{
+		name:  "api-url",
+		usage: "PokeAPI base URL, for self hosted mirrors",
+		apply: func(s *Settings, raw string) error {
+			parsed, err := url.Parse(strings.TrimSpace(raw))
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return fmt.Errorf("invalid URL %q, expected something like https://pokeapi.co/api/v2", raw)
+			}
+			s.APIURL = parsed.String()
+			return nil
+		},
+	},
+	{
+		name:    "debug",
+		usage:   "log PokeAPI requests to stderr",
+		boolean: true,
+		apply: func(s *Settings, raw string) error {
+			debug, err := strconv.ParseBool(strings.TrimSpace(raw))
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", raw)
+			}
+			s.Debug = debug
+			return nil
+		},
+	},
+	{
+		name:    "offline",
+		usage:   "read PokeAPI data from the snapshot directory instead of the network",
+		boolean: true,
+		apply: func(s *Settings, raw string) error {
+			offline, err := strconv.ParseBool(strings.TrimSpace(raw))
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", raw)
+			}
+			s.Offline = offline
+			return nil
+		},
+	},
+	{
+		name:  "snapshot-dir",
+		usage: "directory of PokeAPI data laid out like /api/v2, used by --offline and the snapshot command",
+		apply: func(s *Settings, raw string) error {
+			s.SnapshotDir = raw
+			return nil
+		},
+	},
+	{
+		name:  "seed",
+		usage: "seed for catches and encounters so a session can be replayed, 0 picks a random seed",
+		apply: func(s *Settings, raw string) error {
+			seed, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid seed %q, expected a whole number", raw)
+			}
+			s.Seed = seed
+			return nil
+		},
+	},
+	{
+		name:  "record-dir",
+		usage: "save every PokeAPI request and response to this directory as test fixtures",
+		apply: func(s *Settings, raw string) error {
+			s.RecordDir = raw
+			return nil
+		},
+	},
+	{
+		name:  "output",
+		usage: "how command results are shown: text, json, yaml or table",
+		apply: func(s *Settings, raw string) error {
+			format, err := render.ParseFormat(raw)
+			if err != nil {
+				return err
+			}
+			s.Output = format
+			return nil
+		},
+	},
+}
+
+func Defaults() Settings {
+	return Settings{
+		CacheMaxEntries: 1000,
+		CacheMaxBytes:   64 << 20, //64MB is plenty for a few hundred areas and pokemon
+		HTTPTimeout:     15 * time.Second,
+		HTTPRetries:     3,
+		Output:          render.Text,
+	}
+}
+
+// Load builds the settings from args (without the program name) and the environment. getenv is passed in so tests don't depend on the real environment.
+func Load(args []string, getenv func(string) string) (Settings, error) {
+	s := Defaults()
+
+	fs := flag.NewFlagSet("pokedexcli", flag.ContinueOnError)
+	configFlag := fs.String("config", "", "path to a JSON config file (env "+envPrefix+"CONFIG)")
+	scriptFlag := fs.String("script", "", "run the commands in this file and exit, instead of starting the REPL")
+	flagValues := make(map[string]*flagValue, len(knownSettings))
+	for _, known := range knownSettings {
+		flagValues[known.name] = &flagValue{boolean: known.boolean}
+		fs.Var(flagValues[known.name], known.name, known.usage+" (env "+envName(known.name)+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return s, err
+	}
+	s.Args = fs.Args()
+	s.Script = *scriptFlag
+	if s.Script != "" && len(s.Args) > 0 {
+		return s, fmt.Errorf("--script cannot be combined with a command, put %q in the script instead", strings.Join(s.Args, " "))
+	}
+
+	configPath, required := *configFlag, true
+	if configPath == "" {
+		configPath = getenv(envPrefix + "CONFIG")
+	}
+	if configPath == "" {
+		configPath, required = defaultConfigPath(), false //the default file is optional
+	}
+	if err := s.applyFile(configPath, required); err != nil {
+		return s, err
+	}
+
+	for _, known := range knownSettings {
+		raw := getenv(envName(known.name))
+		if raw == "" {
+			continue
+		}
+		if err := known.apply(&s, raw); err != nil {
+			return s, fmt.Errorf("%s: %w", envName(known.name), err)
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) { //only flags that were actually passed, so an unset flag never hides an env var
+		known, exists := lookupSetting(f.Name)
+		if !exists || flagErr != nil {
+			return
+		}
+		if err := known.apply(&s, flagValues[f.Name].raw); err != nil {
+			flagErr = fmt.Errorf("--%s: %w", f.Name, err)
+		}
+	})
+	return s, flagErr
+}
+
+// NeedsCachePrompt reports whether no source set the cache lifetime at all
+func (s Settings) NeedsCachePrompt() bool {
+	return s.CacheUnit == "" && s.CacheLife == 0
+}
+
+// FillCacheDefaults is used when nothing set the cache lifetime and there is nobody to prompt
+func (s *Settings) FillCacheDefaults() {
+	if s.CacheUnit == "" {
+		s.CacheUnit = "minute"
+	}
+	if s.CacheLife == 0 {
+		s.CacheLife = 5
+	}
+}
+
+// IsTerminal reports whether f is an interactive terminal rather than a pipe or file
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (s *Settings) applyFile(path string, required bool) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() //keeps large integers from turning into floats
+	var values map[string]any
+	if err := dec.Decode(&values); err != nil {
+		return fmt.Errorf("%s: invalid JSON: %w", path, err)
+	}
+
+	for key, value := range values {
+		known, exists := lookupSetting(key)
+		if !exists {
+			return fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+		if err := known.apply(s, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	s.ConfigFile = path
+	return nil
+}
+
+func lookupSetting(name string) (setting, bool) {
+	for _, known := range knownSettings {
+		if known.name == name {
+			return known, true
+		}
+	}
+	return setting{}, false
+}
+
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pokedexcli", "config.json")
+}
+
+func normalizeUnit(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "seconds", "second", "s":
+		return "second", nil
+	case "minutes", "minute", "m":
+		return "minute", nil
+	case "hours", "hour", "h":
+		return "hour", nil
+	default:
+		return "", fmt.Errorf("invalid cache unit %q, expected second, minute or hour", raw)
+	}
+}
+
+func parsePositive(raw string, min int, target *int) error {
+	num, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", raw)
+	}
+	if num < min {
+		return fmt.Errorf("value %d must be at least %d", num, min)
+	}
+	*target = num
+	return nil
+}
@@ -0,0 +1,177 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeEnv(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("unexpected error writing config: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	config := writeConfig(t, `{"cache-unit": "hours", "cache-life": 3, "cache-max-bytes": 67108864}`)
+
+	cases := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantUnit string
+		wantLife int
+		wantArgs []string
+	}{
+		{
+			name:     "config file only",
+			args:     []string{"--config", config},
+			wantUnit: "hour",
+			wantLife: 3,
+		},
+		{
+			name:     "env beats config file",
+			env:      map[string]string{"POKEDEX_CONFIG": config, "POKEDEX_CACHE_LIFE": "7"},
+			wantUnit: "hour",
+			wantLife: 7,
+		},
+		{
+			name:     "flag beats env",
+			args:     []string{"--config", config, "--cache-unit", "s", "--cache-life", "9"},
+			env:      map[string]string{"POKEDEX_CACHE_UNIT": "minute", "POKEDEX_CACHE_LIFE": "7"},
+			wantUnit: "second",
+			wantLife: 9,
+		},
+		{
+			name:     "positional args are kept",
+			args:     []string{"--config", config, "explore", "canalave-city-area"},
+			wantUnit: "hour",
+			wantLife: 3,
+			wantArgs: []string{"explore", "canalave-city-area"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := Load(c.args, fakeEnv(c.env))
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if s.CacheUnit != c.wantUnit || s.CacheLife != c.wantLife {
+				t.Errorf("expected %d %s, got %d %s", c.wantLife, c.wantUnit, s.CacheLife, s.CacheUnit)
+			}
+			if s.CacheMaxBytes != 67108864 {
+				t.Errorf("expected max bytes from config file, got %d", s.CacheMaxBytes)
+			}
+			if strings.Join(s.Args, " ") != strings.Join(c.wantArgs, " ") {
+				t.Errorf("expected args %v, got %v", c.wantArgs, s.Args)
+			}
+		})
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "bad unit flag",
+			args:    []string{"--cache-unit", "days"},
+			wantErr: `--cache-unit: invalid cache unit "days"`,
+		},
+		{
+			name:    "zero life env",
+			env:     map[string]string{"POKEDEX_CACHE_LIFE": "0"},
+			wantErr: "POKEDEX_CACHE_LIFE: value 0 must be at least 1",
+		},
+		{
+			name:    "non integer env",
+			env:     map[string]string{"POKEDEX_CACHE_MAX_ENTRIES": "lots"},
+			wantErr: `POKEDEX_CACHE_MAX_ENTRIES: invalid integer "lots"`,
+		},
+		{
+			name:    "bad timeout",
+			args:    []string{"--http-timeout", "soon"},
+			wantErr: `--http-timeout: invalid duration "soon"`,
+		},
+		{
+			name:    "bad api url",
+			env:     map[string]string{"POKEDEX_API_URL": "pokeapi.co"},
+			wantErr: `POKEDEX_API_URL: invalid URL "pokeapi.co"`,
+		},
+		{
+			name:    "unknown config key",
+			config:  `{"cache-colour": "red"}`,
+			wantErr: `unknown setting "cache-colour"`,
+		},
+		{
+			name:    "broken config file",
+			config:  `{"cache-life": `,
+			wantErr: "invalid JSON",
+		},
+		{
+			name:    "missing explicit config file",
+			args:    []string{"--config", "/does/not/exist.json"},
+			wantErr: "reading config file",
+		},
+		{
+			name:    "bad output format",
+			env:     map[string]string{"POKEDEX_OUTPUT": "xml"},
+			wantErr: `POKEDEX_OUTPUT: invalid output format "xml"`,
+		},
+		{
+			name:    "negative seed",
+			args:    []string{"--seed", "-5"},
+			wantErr: `--seed: invalid seed "-5"`,
+		},
+		{
+			name:    "script and a command",
+			args:    []string{"--script", "tutorial.txt", "pokedex"},
+			wantErr: "--script cannot be combined with a command",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args := c.args
+			if c.config != "" {
+				args = append([]string{"--config", writeConfig(t, c.config)}, args...)
+			}
+			_, err := Load(args, fakeEnv(c.env))
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("expected error containing %q, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNeedsCachePrompt(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) //keep a real config file on this machine out of the test
+	s, err := Load(nil, fakeEnv(nil))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+	if !s.NeedsCachePrompt() {
+		t.Errorf("expected prompt when nothing sets the cache lifetime")
+	}
+	s.FillCacheDefaults()
+	if s.NeedsCachePrompt() || s.CacheUnit != "minute" || s.CacheLife != 5 {
+		t.Errorf("expected defaults of 5 minutes, got %d %s", s.CacheLife, s.CacheUnit)
+	}
+}
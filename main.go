@@ -1,33 +1,153 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/pokeapi"
 	"github.com/CSelvidge/pokedexcli/internal/pokecache"
 	"github.com/CSelvidge/pokedexcli/internal/repl"
-	"github.com/CSelvidge/pokedexcli/internal/actors"
+	"github.com/CSelvidge/pokedexcli/internal/settings"
+	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 )
 
+var cacheTTLRules = []pokecache.TTLRule{ //species data basically never changes, paginated listings might
+	{Pattern: "/pokemon/", TTL: 30 * 24 * time.Hour},
+	{Pattern: "/pokemon-species/", TTL: 30 * 24 * time.Hour},
+	{Pattern: "/location-area?offset=", TTL: 24 * time.Hour},
+}
+
 func main() {
-	cache, err := initCache()
+	opts, err := settings.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		fmt.Printf("Error initializing cache: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error loading settings: %v\n", err)
+		os.Exit(2)
+	}
+	if opts.Script != "" {
+		opts.Args = []string{"run", "--", opts.Script} //--script is shorthand for `pokedexcli run <file>`
+	}
+	oneShot := len(opts.Args) > 0 //`pokedexcli <command> [args]` runs one command, no banner and no prompts
+	if opts.NeedsCachePrompt() && settings.IsTerminal(os.Stdin) && !oneShot {
+		opts.CacheUnit, opts.CacheLife = repl.GetCacheSettings() //last resort, only ask when someone is there to answer
+	}
+	opts.FillCacheDefaults()
+
+	cacheFile := opts.CacheFile
+	if cacheFile == "" {
+		cacheFile = cacheFilePath()
 	}
-	user, err := actors.NewUser()
+	cache, err := initCache(opts, cacheFile)
 	if err != nil {
-		fmt.Printf("Error initializing user: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error initializing cache: %v\n", err)
 		os.Exit(1)
 	}
-	repl.Start(cache, user)
+	profileDir := opts.ProfileDir
+	if profileDir == "" {
+		profileDir = profileDirPath()
+	}
+	var profiles *actors.ProfileStore
+	if profileDir != "" {
+		profiles = actors.NewProfileStore(profileDir)
+	}
+	historyFile := opts.HistoryFile
+	if historyFile == "" && profileDir != "" {
+		historyFile = filepath.Join(filepath.Dir(profileDir), "history")
+	}
+	snapshotDir := opts.SnapshotDir
+	if snapshotDir == "" && profileDir != "" {
+		snapshotDir = filepath.Join(filepath.Dir(profileDir), "snapshot")
+	}
+	var snapshot *pokeapi.Snapshot
+	if snapshotDir != "" {
+		snapshot = pokeapi.NewSnapshot(snapshotDir)
+	}
+	if opts.Offline && snapshot == nil {
+		fmt.Fprintln(os.Stderr, "Offline mode needs a snapshot directory, set --snapshot-dir")
+		os.Exit(2)
+	}
+	replOpts := repl.Options{
+		Client:      newClient(opts, cache, snapshot),
+		Cache:       cache,
+		CacheFile:   cacheFile,
+		Profiles:    profiles,
+		Profile:     opts.Profile,
+		Interactive: settings.IsTerminal(os.Stdin) && settings.IsTerminal(os.Stdout),
+		HistoryFile: historyFile,
+		Output:      opts.Output,
+		Snapshot:    snapshot,
+		Offline:     opts.Offline,
+		Seed:        opts.Seed,
+	}
+	if oneShot {
+		os.Exit(repl.RunCommand(replOpts, opts.Args))
+	}
+	repl.Start(replOpts)
 }
 
-func initCache() (*pokecache.Cache, error) {
+func newClient(opts settings.Settings, cache *pokecache.Cache, snapshot *pokeapi.Snapshot) *pokeapi.Client {
+	retry := pokeapi.DefaultRetryPolicy()
+	retry.MaxAttempts = opts.HTTPRetries + 1
+
+	logLevel := slog.LevelInfo
+	if opts.Debug {
+		logLevel = slog.LevelDebug
+	}
+	httpClient := &http.Client{Timeout: opts.HTTPTimeout}
+	if opts.RecordDir != "" {
+		httpClient.Transport = pokeapi.NewRecorder(opts.RecordDir, nil)
+	}
+	clientConfig := pokeapi.ClientConfig{
+		BaseURL:    opts.APIURL,
+		HTTPClient: httpClient,
+		Retry:      retry,
+		Cache:      cache,
+		Logger:     slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})),
+	}
+	if opts.Offline {
+		clientConfig.Source = snapshot
+	}
+	return pokeapi.NewClient(clientConfig)
+}
+
+func initCache(opts settings.Settings, cacheFile string) (*pokecache.Cache, error) {
 	var err error
-	cache, err := pokecache.NewCache(repl.GetCacheSettings())
+	cache, err := pokecache.NewCache(opts.CacheUnit, opts.CacheLife)
 	if err != nil {
-		fmt.Printf("Error initializing cache: %v\n", err)
 		return nil, err
 	}
+	if err := cache.SetLimits(opts.CacheMaxEntries, opts.CacheMaxBytes); err != nil {
+		return nil, err
+	}
+	cache.SetTTLPolicy(pokecache.NewPatternPolicy(cacheTTLRules...))
+	if cacheFile == "" {
+		return cache, nil
+	}
+	if err := cache.Load(cacheFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not restore cache from %s, starting empty: %v\n", cacheFile, err) //a bad snapshot should never stop the pokedex from starting
+	}
 	return cache, nil
 }
+
+func cacheFilePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "" //no cache dir, run without persistence
+	}
+	return filepath.Join(dir, "pokedexcli", "cache.json")
+}
+
+func profileDirPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "" //no config dir, progress only lasts for this session
+	}
+	return filepath.Join(dir, "pokedexcli", "profiles")
+}